@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/controller/gitstarorg"
+)
+
+// runAdd creates a GitStar tracking the repo, named after the repo unless --name is set
+func runAdd(args []string) int {
+	flags, kube := newFlagSet("add")
+	name := flags.String("name", "", "name of the GitStar, default generated from owner/repo")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	repoName := flags.Arg(0)
+	if parts := strings.Split(repoName, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		fmt.Fprintf(os.Stderr, "repo '%s' is invalid, must be owner/repo\n", repoName)
+		return 2
+	}
+	if *name == "" {
+		*name = gitstarorg.GenerateGitStarName(repoName)
+	}
+
+	c, err := kube.client()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	gitStar := &appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: kube.namespace, Name: *name},
+		Spec:       appv1.GitStarSpec{RepoName: repoName},
+	}
+	if err := c.Create(context.TODO(), gitStar); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("gitstar.app.kuricat.com/%s created\n", gitStar.Name)
+	return 0
+}
@@ -0,0 +1,122 @@
+// kubectl-gitstar is a kubectl plugin managing GitStars, put it in $PATH and run `kubectl gitstar`
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"gitstar-operator/pkg/gitOperation"
+	"gitstar-operator/pkg/resource"
+)
+
+// command is a subcommand of the plugin, it returns the exit code
+type command struct {
+	usage string
+	run   func(args []string) int
+}
+
+var commands map[string]command
+
+// commands are initialized in init because their flag sets print the usage from commands
+func init() {
+	commands = map[string]command{
+		"add":     {usage: "add owner/repo [--name NAME]", run: runAdd},
+		"refresh": {usage: "refresh NAME ...", run: runRefresh},
+		"top":     {usage: "top [--by stars|forks] [--limit N] [-A]", run: runTop},
+		"status":  {usage: "status NAME", run: runStatus},
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command '%s'\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	os.Exit(cmd.run(os.Args[2:]))
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: kubectl gitstar COMMAND [flags]")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+}
+
+// kubeOptions are the kubernetes flags shared by all commands
+type kubeOptions struct {
+	kubeconfig string
+	namespace  string
+}
+
+func newFlagSet(name string) (*pflag.FlagSet, *kubeOptions) {
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: kubectl gitstar %s\n", commands[name].usage)
+		flags.PrintDefaults()
+	}
+
+	o := &kubeOptions{}
+	flags.StringVar(&o.kubeconfig, "kubeconfig", "", "path to a kubeconfig, default $KUBECONFIG or ~/.kube/config")
+	flags.StringVarP(&o.namespace, "namespace", "n", "", "namespace of the GitStars, default the namespace of the current context")
+	return flags, o
+}
+
+// client creates the kubernetes client and resolves the namespace from the current context when it isn't set
+func (o *kubeOptions) client() (client.Client, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	if o.namespace == "" {
+		namespace, _, err := clientConfig.Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.namespace = namespace
+	}
+
+	// read the CronJobs with the API version of the cluster
+	cfg, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	gv, err := resource.DetectCronJobGroupVersion(discoveryClient)
+	if err != nil {
+		return nil, err
+	}
+	resource.SetCronJobGroupVersion(gv)
+
+	return gitOperation.NewK8SClient(o.kubeconfig)
+}
+
+// age formats the time since t like kubectl, "<unknown>" when t isn't set
+func age(t time.Time) string {
+	if t.Unix() <= 0 {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+// runRefresh requests the operator to fetch the numbers of the repos of the GitStars now, it doesn't wait for the
+// fetch, the numbers show up in the status like the ones fetched by the CronJob
+func runRefresh(args []string) int {
+	flags, kube := newFlagSet("refresh")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	c, err := kube.client()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	exitCode := 0
+	now := time.Now()
+	for _, name := range flags.Args() {
+		if err := requestRefresh(context.TODO(), c, kube.namespace, name, now); err != nil {
+			fmt.Fprintf(os.Stderr, "refresh gitstar.app.kuricat.com/%s failed: %v\n", name, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Printf("gitstar.app.kuricat.com/%s refresh requested\n", name)
+	}
+	return exitCode
+}
+
+// requestRefresh sets the refresh annotation of the GitStar to now, the merge patch only touches the annotation so it
+// never conflicts with the writes of the operator
+func requestRefresh(ctx context.Context, c client.Client, namespace, name string, now time.Time) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				appv1.RefreshRequestedAtAnnotation: now.UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	gitStar := &appv1.GitStar{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	return c.Patch(ctx, gitStar, client.RawPatch(types.MergePatchType, patch))
+}
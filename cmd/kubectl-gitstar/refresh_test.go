@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"gitstar-operator/pkg/apis"
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+func newTestClient(t *testing.T, objs ...runtime.Object) client.Client {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewFakeClientWithScheme(s, objs...)
+}
+
+func TestRequestRefresh(t *testing.T) {
+	gitStar := &appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "kubernetes-kubernetes",
+			Annotations: map[string]string{"owner": "kuri-su", appv1.RefreshRequestedAtAnnotation: "2020-04-20T12:00:00Z"},
+		},
+		Spec:   appv1.GitStarSpec{RepoName: "kubernetes/kubernetes"},
+		Status: appv1.GitStarStatus{StarNumber: 42, LastRefreshRequest: "2020-04-20T12:00:00Z"},
+	}
+	c := newTestClient(t, gitStar)
+
+	now := time.Date(2020, 4, 21, 8, 30, 0, 0, time.FixedZone("CST", 8*60*60))
+	if err := requestRefresh(context.TODO(), c, "default", "kubernetes-kubernetes", now); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &appv1.GitStar{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kubernetes-kubernetes"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Annotations[appv1.RefreshRequestedAtAnnotation]; v != "2020-04-21T00:30:00Z" {
+		t.Errorf("refresh annotation = %q, want the current time in UTC", v)
+	}
+	if v := got.Annotations["owner"]; v != "kuri-su" {
+		t.Errorf("owner annotation = %q, want the other annotations kept", v)
+	}
+	// the plugin only requests the refresh, the operator fetches and updates the status
+	if got.Spec.RepoName != "kubernetes/kubernetes" || got.Status.StarNumber != 42 || got.Status.LastRefreshRequest != "2020-04-20T12:00:00Z" {
+		t.Errorf("GitStar = %+v, want the spec and status untouched", got)
+	}
+
+	err := requestRefresh(context.TODO(), c, "default", "missing", now)
+	if !errors.IsNotFound(err) {
+		t.Errorf("refresh of a missing GitStar = %v, want NotFound", err)
+	}
+}
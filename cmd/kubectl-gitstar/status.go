@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/resource"
+)
+
+// recentJobsLimit is the number of Jobs printed by status
+const recentJobsLimit = 5
+
+// runStatus prints the status of a GitStar, its CronJob and the recent Jobs of the CronJob
+func runStatus(args []string) int {
+	flags, kube := newFlagSet("status")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	c, err := kube.client()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	gitStar := &appv1.GitStar{}
+	err = c.Get(context.TODO(), types.NamespacedName{Namespace: kube.namespace, Name: flags.Arg(0)}, gitStar)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", gitStar.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", gitStar.Namespace)
+	fmt.Fprintf(w, "Repo:\t%s\n", gitStar.Spec.RepoName)
+	fmt.Fprintf(w, "Stars:\t%d\n", gitStar.Status.StarNumber)
+	fmt.Fprintf(w, "Forks:\t%d\n", gitStar.Status.ForkNumber)
+	fmt.Fprintf(w, "Updated:\t%s\n", age(gitStar.Status.UpdatedAt.Time))
+	fmt.Fprintf(w, "Failed Reason:\t%s\n", orNone(gitStar.Status.FailedReason))
+
+	cronJobName := resource.GenerateCronJobName(gitStar)
+	cronJob, err := resource.GetCronJob(context.TODO(), c, types.NamespacedName{Namespace: gitStar.Namespace, Name: cronJobName})
+	switch {
+	case errors.IsNotFound(err):
+		fmt.Fprintf(w, "CronJob:\t%s (missing)\n", cronJobName)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	default:
+		fmt.Fprintf(w, "CronJob:\t%s\n", cronJob.Name)
+		fmt.Fprintf(w, "  Schedule:\t%s\n", cronJob.Spec.Schedule)
+		fmt.Fprintf(w, "  Suspend:\t%t\n", cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend)
+		lastSchedule := "<none>"
+		if cronJob.Status.LastScheduleTime != nil {
+			lastSchedule = age(cronJob.Status.LastScheduleTime.Time) + " ago"
+		}
+		fmt.Fprintf(w, "  Last Schedule:\t%s\n", lastSchedule)
+		fmt.Fprintf(w, "  Active Jobs:\t%d\n", len(cronJob.Status.Active))
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	jobs, err := recentJobs(c, gitStar.Namespace, cronJobName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println()
+	if len(jobs) == 0 {
+		fmt.Println("No recent Jobs.")
+		return 0
+	}
+	w = tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprint(w, "JOB\tSTATUS\tSTARTED\tDURATION\n")
+	for _, job := range jobs {
+		started, took := "<none>", "<none>"
+		if job.Status.StartTime != nil {
+			started = age(job.Status.StartTime.Time) + " ago"
+			if job.Status.CompletionTime != nil {
+				took = job.Status.CompletionTime.Sub(job.Status.StartTime.Time).String()
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", job.Name, jobStatus(&job), started, took)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// recentJobs lists the latest Jobs created by the CronJob, newest first
+func recentJobs(c client.Client, namespace, cronJobName string) ([]batchv1.Job, error) {
+	jobList := &batchv1.JobList{}
+	if err := c.List(context.TODO(), jobList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	var jobs []batchv1.Job
+	for _, job := range jobList.Items {
+		if owner := metav1.GetControllerOf(&job); owner != nil && owner.Kind == "CronJob" && owner.Name == cronJobName {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[j].CreationTimestamp.Before(&jobs[i].CreationTimestamp)
+	})
+	if len(jobs) > recentJobsLimit {
+		jobs = jobs[:recentJobsLimit]
+	}
+	return jobs, nil
+}
+
+func jobStatus(job *batchv1.Job) string {
+	switch {
+	case job.Status.Succeeded > 0:
+		return "Complete"
+	case job.Status.Failed > 0 && job.Status.Active == 0:
+		return "Failed"
+	case job.Status.Active > 0:
+		return "Running"
+	default:
+		return "Pending"
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+// topSortKeys are the numbers top can rank the GitStars by
+var topSortKeys = map[string]func(*appv1.GitStar) int64{
+	"stars": func(g *appv1.GitStar) int64 { return g.Status.StarNumber },
+	"forks": func(g *appv1.GitStar) int64 { return g.Status.ForkNumber },
+}
+
+// runTop prints the GitStars ranked by their stars or forks
+func runTop(args []string) int {
+	flags, kube := newFlagSet("top")
+	by := flags.String("by", "stars", "rank by stars or forks")
+	limit := flags.Int("limit", 10, "number of GitStars to print, 0 prints all")
+	allNamespaces := flags.BoolP("all-namespaces", "A", false, "rank the GitStars of all namespaces")
+	selector := flags.StringP("selector", "l", "", "only rank the GitStars matching this label selector")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	value, ok := topSortKeys[*by]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "--by '%s' is invalid, must be stars or forks\n", *by)
+		return 2
+	}
+	s, err := labels.Parse(*selector)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	c, err := kube.client()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	namespace := kube.namespace
+	if *allNamespaces {
+		namespace = ""
+	}
+	gitStars := &appv1.GitStarList{}
+	err = c.List(context.TODO(), gitStars, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: s})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	items := gitStars.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		if value(&items[i]) != value(&items[j]) {
+			return value(&items[i]) > value(&items[j])
+		}
+		if items[i].Namespace != items[j].Namespace {
+			return items[i].Namespace < items[j].Namespace
+		}
+		return items[i].Name < items[j].Name
+	})
+	if *limit > 0 && len(items) > *limit {
+		items = items[:*limit]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	if *allNamespaces {
+		fmt.Fprint(w, "RANK\tNAMESPACE\tNAME\tREPO\tSTARS\tFORKS\tUPDATED\n")
+	} else {
+		fmt.Fprint(w, "RANK\tNAME\tREPO\tSTARS\tFORKS\tUPDATED\n")
+	}
+	for i := range items {
+		gitStar := &items[i]
+		if *allNamespaces {
+			fmt.Fprintf(w, "%d\t%s\t", i+1, gitStar.Namespace)
+		} else {
+			fmt.Fprintf(w, "%d\t", i+1)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", gitStar.Name, gitStar.Spec.RepoName,
+			gitStar.Status.StarNumber, gitStar.Status.ForkNumber, age(gitStar.Status.UpdatedAt.Time))
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
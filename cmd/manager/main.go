@@ -10,11 +10,18 @@ import (
 	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	"k8s.io/client-go/discovery"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 
 	"gitstar-operator/pkg/apis"
+	appv1 "gitstar-operator/pkg/apis/app/v1"
 	"gitstar-operator/pkg/controller"
+	"gitstar-operator/pkg/controller/gitstar"
+	"gitstar-operator/pkg/gitOperation"
+	"gitstar-operator/pkg/operatorconfig"
+	"gitstar-operator/pkg/resource"
+	"gitstar-operator/pkg/webserver"
 	"gitstar-operator/version"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
@@ -25,9 +32,13 @@ import (
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
 	"github.com/spf13/pflag"
 	v1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	ctrlcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
@@ -48,6 +59,51 @@ func printVersion() {
 	log.Info(fmt.Sprintf("Version of operator-sdk: %v", sdkVersion.Version))
 }
 
+// loadJobDefaults reads the defaults of the queryJob pods from the job template file, overlaid by the image and
+// service account flags
+func loadJobDefaults(file, image, serviceAccount string) (*appv1.GitStarJobTemplate, error) {
+	defaults := &appv1.GitStarJobTemplate{}
+	if file != "" {
+		var err error
+		if defaults, err = resource.LoadJobTemplate(file); err != nil {
+			return nil, err
+		}
+	}
+	if image != "" {
+		defaults.Image = image
+	}
+	if serviceAccount != "" {
+		defaults.ServiceAccountName = serviceAccount
+	}
+	return defaults, nil
+}
+
+// loadMetricsPorts overrides the metrics ports with the ones of the GitStarOperatorConfig, they are only read at start
+func loadMetricsPorts(ctx context.Context, cfg *rest.Config) error {
+	scheme := k8sruntime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		return err
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	operatorConfig, err := operatorconfig.Get(ctx, c)
+	if err != nil || operatorConfig == nil {
+		return err
+	}
+	if err := operatorconfig.Validate(&operatorConfig.Spec); err != nil {
+		return err
+	}
+	if operatorConfig.Spec.MetricsPort != nil {
+		metricsPort = *operatorConfig.Spec.MetricsPort
+	}
+	if operatorConfig.Spec.OperatorMetricsPort != nil {
+		operatorMetricsPort = *operatorConfig.Spec.OperatorMetricsPort
+	}
+	return nil
+}
+
 func main() {
 	// Add the zap logger flag set to the CLI. The flag set must
 	// be added before calling pflag.Parse().
@@ -57,8 +113,39 @@ func main() {
 	// controller-runtime)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 
+	apiBindAddress := pflag.String("api-bind-address", "",
+		"address the HTTP read API listens on, like ':8080', disabled when empty")
+	apiToken := pflag.String("api-token", "",
+		"bearer token required by the HTTP read API, default env 'GITSTAR_API_TOKEN', no authentication when empty")
+	apiCORSAllowedOrigins := pflag.StringSlice("api-cors-allowed-origins", nil,
+		"origins allowed to call the HTTP read API from a browser, '*' allows all of them")
+	maxConcurrentReconciles := pflag.Int("max-concurrent-reconciles", 1,
+		"number of objects each controller reconciles at the same time")
+	fetchLimits := gitOperation.DefaultFetchLimits()
+	fetchLimits.AddFlags(pflag.CommandLine)
+	jobTemplateFile := pflag.String("job-template-file", "",
+		"YAML file with the default job template of the queryJob pods, e.g. a mounted ConfigMap, see spec.jobTemplate of GitStar")
+	jobImage := pflag.String("job-image", "",
+		"default image of the queryJob pods, overrides --job-template-file, default '"+resource.DefaultJobImage+"'")
+	jobServiceAccount := pflag.String("job-service-account", "",
+		"default service account of the queryJob pods, overrides --job-template-file, default '"+resource.DefaultJobServiceAccountName+"'")
+	webhookPort := pflag.Int("webhook-port", 9443,
+		"port of the conversion webhook of GitStars")
+	webhookCertDir := pflag.String("webhook-cert-dir", "",
+		"directory with tls.crt and tls.key of the conversion webhook of GitStars, the webhook is disabled when empty")
+	pflag.IntVar(&gitstar.RefreshWorkers, "refresh-workers", gitstar.RefreshWorkers,
+		"number of GitStars fetched in the background at the same time, at least --max-concurrent-reconciles")
+	pflag.DurationVar(&gitstar.RefreshTimeout, "refresh-timeout", gitstar.RefreshTimeout,
+		"time allowed for the background fetch of one GitStar, including its optional collectors")
+	pflag.StringVar(&operatorconfig.Name, "config-name", operatorconfig.Name,
+		"name of the cluster-scoped GitStarOperatorConfig read by the operator")
+
 	pflag.Parse()
 
+	if *apiToken == "" {
+		*apiToken = os.Getenv("GITSTAR_API_TOKEN")
+	}
+
 	// Use a zap logr.Logger implementation. If none of the zap
 	// flags are configured (or if the zap flag set is not being
 	// used), this defaults to a production zap logger.
@@ -71,6 +158,20 @@ func main() {
 
 	printVersion()
 
+	gitOperation.SetFetchLimits(fetchLimits)
+
+	// the operator reads v1 GitStars, where they are stored as v2 the apiserver can't serve them without the webhook
+	if *webhookCertDir == "" {
+		log.Info("The conversion webhook of GitStars is disabled, --webhook-cert-dir is empty. GitStars stored as v2 can't be read, see deploy/webhook.yaml")
+	}
+
+	jobDefaults, err := loadJobDefaults(*jobTemplateFile, *jobImage, *jobServiceAccount)
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	resource.SetJobDefaults(*jobDefaults)
+
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
@@ -92,12 +193,35 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Write the CronJobs in batch/v1 where the apiserver serves it, batch/v1beta1 is removed in Kubernetes 1.25
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	cronJobGroupVersion, err := resource.DetectCronJobGroupVersion(discoveryClient)
+	if err != nil {
+		log.Error(err, "Failed to detect the API version of CronJobs")
+		os.Exit(1)
+	}
+	resource.SetCronJobGroupVersion(cronJobGroupVersion)
+	log.Info("Detected the API version of CronJobs", "GroupVersion", cronJobGroupVersion.String())
+
+	if err := loadMetricsPorts(ctx, cfg); err != nil {
+		log.Info("Could not read the metrics ports of the GitStarOperatorConfig, use the defaults", "error", err.Error())
+	}
+
 	// Set default manager options
 	options := manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
 	}
 
+	if *webhookCertDir != "" {
+		options.Port = *webhookPort
+		options.CertDir = *webhookCertDir
+	}
+
 	// Add support for MultiNamespace set in WATCH_NAMESPACE (e.g ns1,ns2)
 	// Note that this is not intended to be used for excluding namespaces, this is better done via a Predicate
 	// Also note that you may face performance issues when using this with a high number of namespaces.
@@ -123,11 +247,32 @@ func main() {
 	}
 
 	// Setup all Controllers
-	if err := controller.AddToManager(mgr); err != nil {
+	if err := controller.AddToManager(mgr, ctrlcontroller.Options{MaxConcurrentReconciles: *maxConcurrentReconciles}); err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
 
+	// Serve the conversion between the v1 and v2 GitStars, v2 is the storage version
+	if *webhookCertDir != "" {
+		if err := builder.WebhookManagedBy(mgr).For(&appv1.GitStar{}).Complete(); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
+	// Add the HTTP read API, it serves from the cache of the manager
+	if *apiBindAddress != "" {
+		err := mgr.Add(webserver.New(mgr.GetCache(), webserver.Options{
+			BindAddress:        *apiBindAddress,
+			Token:              *apiToken,
+			CORSAllowedOrigins: *apiCORSAllowedOrigins,
+		}))
+		if err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	// Add the Metrics Service
 	addMetrics(ctx, cfg)
 	log.Info("Starting the Watcher.")
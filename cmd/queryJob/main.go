@@ -1,10 +1,16 @@
 package main
 
 import (
+	"os"
+
 	"github.com/operator-framework/operator-sdk/pkg/log/zap"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/labels"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"gitstar-operator/pkg/gitOperation"
+	"gitstar-operator/pkg/operatorconfig"
+	"gitstar-operator/pkg/resource"
 )
 
 var (
@@ -12,7 +18,55 @@ var (
 )
 
 func main() {
+	// "stars" queries the repos directly from GitHub, without kubernetes
+	if len(os.Args) > 1 && os.Args[1] == "stars" {
+		os.Exit(runStars(os.Args[2:]))
+	}
+
+	var (
+		kubeconfig = pflag.String("kubeconfig", "", "path to a kubeconfig, default the in cluster config, then $KUBECONFIG or ~/.kube/config")
+		namespace  = pflag.StringP("namespace", "n", "", "namespace of the GitStar, default env 'git_star_name_space'; with --all or --selector empty means all namespaces")
+		name       = pflag.String("name", "", "name of the GitStar, default env 'git_star_name'")
+		all        = pflag.Bool("all", false, "refresh all GitStars in --namespace")
+		selector   = pflag.StringP("selector", "l", "", "refresh the GitStars in --namespace matching this label selector")
+		dryRun     = pflag.Bool("dry-run", false, "fetch the star numbers without updating the GitStar status")
+		configName = pflag.String("config-name", os.Getenv(resource.ENVGitStarConfigName),
+			"name of the GitStarOperatorConfig with the credentials unless the env of the CronJob sets them, default env 'git_star_config_name', then '"+operatorconfig.Name+"'")
+	)
+	fetchLimits := gitOperation.DefaultFetchLimits()
+	fetchLimits.AddFlags(pflag.CommandLine)
+	pflag.Parse()
+	gitOperation.SetFetchLimits(fetchLimits)
+	if *configName != "" {
+		operatorconfig.Name = *configName
+	}
+
 	log.Info("start")
 
-	gitOperation.Run("", "")
+	c, err := gitOperation.NewK8SClient(*kubeconfig)
+	if err != nil {
+		log.Error(err, "new kubernetes client was failed! ")
+		os.Exit(1)
+	}
+
+	runner := gitOperation.NewRunner(c)
+	runner.DryRun = *dryRun
+
+	if *all || *selector != "" {
+		s, err := labels.Parse(*selector)
+		if err != nil {
+			log.Error(err, "parse selector was failed! ")
+			os.Exit(1)
+		}
+		if err := runner.RunAll(*namespace, s); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := runner.Run(*namespace, *name); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
 }
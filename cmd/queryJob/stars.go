@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"gitstar-operator/pkg/gitOperation"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputCSV   = "csv"
+)
+
+// repoRow is one line of the output of the stars command
+type repoRow struct {
+	Repo       string `json:"repo"`
+	Stars      int64  `json:"stars"`
+	Forks      int64  `json:"forks"`
+	OpenIssues int64  `json:"openIssues"`
+	Watchers   int64  `json:"watchers"`
+	Error      string `json:"error,omitempty"`
+}
+
+// runStars prints the numbers of the repos given as arguments directly from GitHub, without kubernetes
+func runStars(args []string) int {
+	flags := pflag.NewFlagSet("stars", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s stars [flags] owner/repo ...\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	output := flags.StringP("output", "o", outputTable, "output format, one of table, json, yaml, csv")
+	token := flags.String("token", os.Getenv("GITHUB_TOKEN"), "GitHub personal access token, default env 'GITHUB_TOKEN'")
+	fetchLimits := gitOperation.DefaultFetchLimits()
+	fetchLimits.AddFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	gitOperation.SetFetchLimits(fetchLimits)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	var write func(io.Writer, []repoRow) error
+	switch *output {
+	case outputTable:
+		write = writeTable
+	case outputJSON:
+		write = writeJSON
+	case outputYAML:
+		write = writeYAML
+	case outputCSV:
+		write = writeCSV
+	default:
+		fmt.Fprintf(os.Stderr, "unknown output format '%s'\n", *output)
+		return 2
+	}
+
+	fetcher := gitOperation.NewGitHubFetcher(*token)
+	exitCode := 0
+	rows := make([]repoRow, 0, flags.NArg())
+	for _, repoName := range flags.Args() {
+		row := repoRow{Repo: repoName}
+		stats, err := fetcher.FetchRepoStats(context.TODO(), repoName)
+		if err != nil {
+			row.Error = err.Error()
+			exitCode = 1
+		} else {
+			row.Stars = stats.StarNumber
+			row.Forks = stats.ForkNumber
+			row.OpenIssues = stats.OpenIssueNumber
+			row.Watchers = stats.WatcherNumber
+		}
+		rows = append(rows, row)
+	}
+
+	if err := write(os.Stdout, rows); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return exitCode
+}
+
+func writeTable(w io.Writer, rows []repoRow) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tSTARS\tFORKS\tOPEN ISSUES\tWATCHERS\tERROR")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\n", row.Repo, row.Stars, row.Forks, row.OpenIssues, row.Watchers, row.Error)
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, rows []repoRow) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+func writeYAML(w io.Writer, rows []repoRow) error {
+	data, err := yaml.Marshal(rows)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func writeCSV(w io.Writer, rows []repoRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"repo", "stars", "forks", "openIssues", "watchers", "error"}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		err := cw.Write([]string{
+			row.Repo,
+			strconv.FormatInt(row.Stars, 10),
+			strconv.FormatInt(row.Forks, 10),
+			strconv.FormatInt(row.OpenIssues, 10),
+			strconv.FormatInt(row.Watchers, 10),
+			row.Error,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
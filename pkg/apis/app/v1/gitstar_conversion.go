@@ -0,0 +1,375 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v2 "gitstar-operator/pkg/apis/app/v2"
+)
+
+// ConversionDataAnnotation keeps the fields of a v2 GitStar v1 can't express, so a v1 client doesn't drop them
+const ConversionDataAnnotation = "gitstar.app.kuricat.com/v2-conversion-data"
+
+// conversionData are the fields of a v2 GitStar missing in v1
+type conversionData struct {
+	// Repository is only kept when it doesn't round-trip through repoName
+	Repository *v2.RepositoryReference `json:"repository,omitempty"`
+	Provider   string                  `json:"provider,omitempty"`
+	Schedule   string                  `json:"schedule,omitempty"`
+	Conditions []v2.GitStarCondition   `json:"conditions,omitempty"`
+	// UpdatedAt tells whether the Ready condition is stale, it is recomputed when the v1 status changed
+	UpdatedAt *metav1.Time `json:"updatedAt,omitempty"`
+
+	// RepoName and Repositories keep the repo names of a v1 GitStar that aren't owner/name, they are kept in the
+	// annotation of the v2 GitStar and restored while its repositories are unchanged
+	RepoName     string   `json:"repoName,omitempty"`
+	Repositories []string `json:"repositories,omitempty"`
+}
+
+var _ conversion.Convertible = &GitStar{}
+
+// ConvertTo converts this GitStar to the v2 hub
+func (in *GitStar) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v2.GitStar)
+	if !ok {
+		return fmt.Errorf("unsupported conversion of GitStar to %T", dstRaw)
+	}
+
+	data := &conversionData{}
+	if raw, ok := in.Annotations[ConversionDataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(raw), data); err != nil {
+			return fmt.Errorf("annotation %s is invalid: %v", ConversionDataAnnotation, err)
+		}
+	}
+
+	in.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	delete(dst.Annotations, ConversionDataAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	// the v1 schema only admits owner/name, older objects may have other repo names, they are converted as they are
+	// and kept in the annotation so the conversion never fails
+	valid := validRepoName(in.Spec.RepoName)
+	for _, repoName := range in.Spec.Repositories {
+		valid = valid && validRepoName(repoName)
+	}
+	if !valid {
+		raw, err := json.Marshal(conversionData{RepoName: in.Spec.RepoName, Repositories: in.Spec.Repositories})
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[ConversionDataAnnotation] = string(raw)
+	}
+
+	dst.Spec = v2.GitStarSpec{
+		Repository:           splitRepoName(in.Spec.RepoName),
+		Provider:             data.Provider,
+		Schedule:             data.Schedule,
+		JobTemplate:          (*v2.GitStarJobTemplate)(in.Spec.JobTemplate.DeepCopy()),
+		TrackStargazers:      in.Spec.TrackStargazers,
+		StargazerLimit:       in.Spec.StargazerLimit,
+		BackfillHistory:      in.Spec.BackfillHistory,
+		BackfillRequestLimit: in.Spec.BackfillRequestLimit,
+		TrackReleases:        in.Spec.TrackReleases,
+		TrackActivity:        in.Spec.TrackActivity,
+		TrackContributors:    in.Spec.TrackContributors,
+		TopContributors:      in.Spec.TopContributors,
+		TrackTraffic:         in.Spec.TrackTraffic,
+	}
+	if data.Repository != nil && joinRepository(*data.Repository) == in.Spec.RepoName {
+		dst.Spec.Repository = *data.Repository
+	}
+	for _, repoName := range in.Spec.Repositories {
+		dst.Spec.Repositories = append(dst.Spec.Repositories, splitRepoName(repoName))
+	}
+
+	dst.Status = v2.GitStarStatus{
+		Metrics: v2.GitStarMetrics{
+			Stars: in.Status.StarNumber,
+			Forks: in.Status.ForkNumber,
+		},
+		Conditions:         data.Conditions,
+		LastRefreshRequest: in.Status.LastRefreshRequest,
+		Stargazers:         (*v2.GitStarStargazersStatus)(in.Status.Stargazers.DeepCopy()),
+		History:            (*v2.GitStarHistoryStatus)(in.Status.History.DeepCopy()),
+		LatestRelease:      (*v2.GitStarReleaseStatus)(in.Status.LatestRelease.DeepCopy()),
+		Activity:           (*v2.GitStarActivityStatus)(in.Status.Activity.DeepCopy()),
+		Contributors:       convertContributorsTo(in.Status.Contributors),
+		Traffic:            convertTrafficTo(in.Status.Traffic),
+	}
+	if !in.Status.UpdatedAt.IsZero() {
+		dst.Status.UpdatedAt = in.Status.UpdatedAt.DeepCopy()
+	}
+	for i := range in.Status.Repositories {
+		repository := &in.Status.Repositories[i]
+		status := v2.GitStarRepositoryStatus{
+			Repository:   splitRepoName(repository.RepoName),
+			Metrics:      v2.GitStarMetrics{Stars: repository.StarNumber, Forks: repository.ForkNumber},
+			FailedReason: repository.FailedReason,
+		}
+		if !repository.UpdatedAt.IsZero() {
+			status.UpdatedAt = repository.UpdatedAt.DeepCopy()
+		}
+		dst.Status.Repositories = append(dst.Status.Repositories, status)
+	}
+	// the Ready condition is kept unless a v1 client changed the fetch result, its transition time is derived from
+	// the v1 object so converting it again gives the same condition
+	if failedReasonOf(&dst.Status) != in.Status.FailedReason || !timeEqual(data.UpdatedAt, dst.Status.UpdatedAt) {
+		transitionTime := in.CreationTimestamp
+		if !in.Status.UpdatedAt.IsZero() {
+			transitionTime = in.Status.UpdatedAt
+		}
+		setReady(&dst.Status, in.Status.FailedReason, transitionTime)
+	}
+	return nil
+}
+
+// ConvertFrom converts the v2 hub to this GitStar
+func (in *GitStar) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v2.GitStar)
+	if !ok {
+		return fmt.Errorf("unsupported conversion of %T to GitStar", srcRaw)
+	}
+
+	src.ObjectMeta.DeepCopyInto(&in.ObjectMeta)
+	in.Spec = GitStarSpec{
+		RepoName:             joinRepository(src.Spec.Repository),
+		JobTemplate:          (*GitStarJobTemplate)(src.Spec.JobTemplate.DeepCopy()),
+		TrackStargazers:      src.Spec.TrackStargazers,
+		StargazerLimit:       src.Spec.StargazerLimit,
+		BackfillHistory:      src.Spec.BackfillHistory,
+		BackfillRequestLimit: src.Spec.BackfillRequestLimit,
+		TrackReleases:        src.Spec.TrackReleases,
+		TrackActivity:        src.Spec.TrackActivity,
+		TrackContributors:    src.Spec.TrackContributors,
+		TopContributors:      src.Spec.TopContributors,
+		TrackTraffic:         src.Spec.TrackTraffic,
+	}
+	in.Status = GitStarStatus{
+		StarNumber:         src.Status.Metrics.Stars,
+		ForkNumber:         src.Status.Metrics.Forks,
+		FailedReason:       failedReasonOf(&src.Status),
+		LastRefreshRequest: src.Status.LastRefreshRequest,
+		Stargazers:         (*GitStarStargazersStatus)(src.Status.Stargazers.DeepCopy()),
+		History:            (*GitStarHistoryStatus)(src.Status.History.DeepCopy()),
+		LatestRelease:      (*GitStarReleaseStatus)(src.Status.LatestRelease.DeepCopy()),
+		Activity:           (*GitStarActivityStatus)(src.Status.Activity.DeepCopy()),
+		Contributors:       convertContributorsFrom(src.Status.Contributors),
+		Traffic:            convertTrafficFrom(src.Status.Traffic),
+	}
+	if src.Status.UpdatedAt != nil {
+		in.Status.UpdatedAt = *src.Status.UpdatedAt.DeepCopy()
+	}
+	for _, repository := range src.Spec.Repositories {
+		in.Spec.Repositories = append(in.Spec.Repositories, joinRepository(repository))
+	}
+	// restore the repo names a v1 GitStar had before it was stored as v2
+	names := &conversionData{}
+	if err := json.Unmarshal([]byte(src.Annotations[ConversionDataAnnotation]), names); err == nil {
+		if names.RepoName != "" && splitRepoName(names.RepoName) == src.Spec.Repository {
+			in.Spec.RepoName = names.RepoName
+		}
+		if repositoriesOf(names.Repositories, src.Spec.Repositories) {
+			in.Spec.Repositories = names.Repositories
+		}
+	}
+	for i := range src.Status.Repositories {
+		repository := &src.Status.Repositories[i]
+		status := GitStarRepositoryStatus{
+			RepoName:     joinRepository(repository.Repository),
+			StarNumber:   repository.Metrics.Stars,
+			ForkNumber:   repository.Metrics.Forks,
+			FailedReason: repository.FailedReason,
+		}
+		if repository.UpdatedAt != nil {
+			status.UpdatedAt = *repository.UpdatedAt.DeepCopy()
+		}
+		in.Status.Repositories = append(in.Status.Repositories, status)
+	}
+
+	data := conversionData{
+		Provider:   src.Spec.Provider,
+		Schedule:   src.Spec.Schedule,
+		Conditions: src.Status.Conditions,
+		UpdatedAt:  src.Status.UpdatedAt,
+	}
+	if splitRepoName(in.Spec.RepoName) != src.Spec.Repository {
+		data.Repository = &src.Spec.Repository
+	}
+	if data.Repository == nil && data.Provider == "" && data.Schedule == "" && len(data.Conditions) == 0 && data.UpdatedAt == nil {
+		delete(in.Annotations, ConversionDataAnnotation)
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if in.Annotations == nil {
+		in.Annotations = map[string]string{}
+	}
+	in.Annotations[ConversionDataAnnotation] = string(raw)
+	return nil
+}
+
+// Schedule returns spec.schedule of the v2 GitStar, empty when it isn't set
+func (in *GitStar) Schedule() string {
+	data := &conversionData{}
+	if err := json.Unmarshal([]byte(in.Annotations[ConversionDataAnnotation]), data); err != nil {
+		return ""
+	}
+	return data.Schedule
+}
+
+// validRepoName tells whether repoName is owner/name, like the v1 schema requires
+func validRepoName(repoName string) bool {
+	repository := splitRepoName(repoName)
+	return repository.Owner != "" && repository.Name != "" && !strings.Contains(repository.Name, "/")
+}
+
+// repositoriesOf tells whether the v2 repositories were converted from the v1 repo names
+func repositoriesOf(repoNames []string, repositories []v2.RepositoryReference) bool {
+	if len(repoNames) == 0 || len(repoNames) != len(repositories) {
+		return false
+	}
+	for i, repoName := range repoNames {
+		if splitRepoName(repoName) != repositories[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitRepoName splits owner/name at the first slash
+func splitRepoName(repoName string) v2.RepositoryReference {
+	if i := strings.Index(repoName, "/"); i > 0 {
+		return v2.RepositoryReference{Owner: repoName[:i], Name: repoName[i+1:]}
+	}
+	return v2.RepositoryReference{Name: repoName}
+}
+
+func joinRepository(repository v2.RepositoryReference) string {
+	if repository.Owner == "" {
+		return repository.Name
+	}
+	return repository.Owner + "/" + repository.Name
+}
+
+// convertContributorsTo converts status.contributors to v2, the contributors have the same fields in both versions
+func convertContributorsTo(in *GitStarContributorsStatus) *v2.GitStarContributorsStatus {
+	if in == nil {
+		return nil
+	}
+	out := &v2.GitStarContributorsStatus{Count: in.Count, FailedReason: in.FailedReason}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	for _, contributor := range in.Top {
+		out.Top = append(out.Top, v2.GitStarContributor(contributor))
+	}
+	return out
+}
+
+func convertContributorsFrom(in *v2.GitStarContributorsStatus) *GitStarContributorsStatus {
+	if in == nil {
+		return nil
+	}
+	out := &GitStarContributorsStatus{Count: in.Count, FailedReason: in.FailedReason}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	for _, contributor := range in.Top {
+		out.Top = append(out.Top, GitStarContributor(contributor))
+	}
+	return out
+}
+
+// convertTrafficTo converts status.traffic to v2, the referrers have the same fields in both versions
+func convertTrafficTo(in *GitStarTrafficStatus) *v2.GitStarTrafficStatus {
+	if in == nil {
+		return nil
+	}
+	copied := in.DeepCopy()
+	out := &v2.GitStarTrafficStatus{
+		TrafficName:    copied.TrafficName,
+		Views:          copied.Views,
+		UniqueVisitors: copied.UniqueVisitors,
+		Clones:         copied.Clones,
+		UniqueCloners:  copied.UniqueCloners,
+		Days:           copied.Days,
+		SkippedRepos:   copied.SkippedRepos,
+		UpdatedAt:      copied.UpdatedAt,
+		FailedReason:   copied.FailedReason,
+	}
+	for _, referrer := range copied.TopReferrers {
+		out.TopReferrers = append(out.TopReferrers, v2.GitStarReferrer(referrer))
+	}
+	return out
+}
+
+func convertTrafficFrom(in *v2.GitStarTrafficStatus) *GitStarTrafficStatus {
+	if in == nil {
+		return nil
+	}
+	copied := in.DeepCopy()
+	out := &GitStarTrafficStatus{
+		TrafficName:    copied.TrafficName,
+		Views:          copied.Views,
+		UniqueVisitors: copied.UniqueVisitors,
+		Clones:         copied.Clones,
+		UniqueCloners:  copied.UniqueCloners,
+		Days:           copied.Days,
+		SkippedRepos:   copied.SkippedRepos,
+		UpdatedAt:      copied.UpdatedAt,
+		FailedReason:   copied.FailedReason,
+	}
+	for _, referrer := range copied.TopReferrers {
+		out.TopReferrers = append(out.TopReferrers, GitStarReferrer(referrer))
+	}
+	return out
+}
+
+// failedReasonOf returns the message of a False Ready condition
+func failedReasonOf(status *v2.GitStarStatus) string {
+	ready := status.Condition(v2.ConditionReady)
+	if ready == nil || ready.Status != corev1.ConditionFalse {
+		return ""
+	}
+	if ready.Message != "" {
+		return ready.Message
+	}
+	return ready.Reason
+}
+
+// setReady sets the Ready condition from the result of the last fetch, it is left unset before the first one. The
+// transition time is only used when the status of the condition changes.
+func setReady(status *v2.GitStarStatus, failedReason string, transitionTime metav1.Time) {
+	switch {
+	case failedReason != "":
+		status.SetCondition(v2.GitStarCondition{
+			Type:               v2.ConditionReady,
+			Status:             corev1.ConditionFalse,
+			LastTransitionTime: transitionTime,
+			Reason:             v2.ReasonFetchFailed,
+			Message:            failedReason,
+		})
+	case status.UpdatedAt != nil:
+		status.SetCondition(v2.GitStarCondition{
+			Type:               v2.ConditionReady,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: transitionTime,
+			Reason:             v2.ReasonFetched,
+		})
+	}
+}
+
+func timeEqual(a, b *metav1.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(b)
+}
@@ -0,0 +1,266 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v2 "gitstar-operator/pkg/apis/app/v2"
+)
+
+func TestConvertRoundTripV1(t *testing.T) {
+	updatedAt := metav1.NewTime(time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC))
+	tests := []*GitStar{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "default"},
+			Spec:       GitStarSpec{RepoName: "kuri-su/kblog"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "fetched", Namespace: "default", Labels: map[string]string{"team": "blog"}},
+			Spec: GitStarSpec{
+				RepoName:    "kuri-su/kblog",
+				JobTemplate: &GitStarJobTemplate{Image: "queryjob:v1", Env: []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy"}}},
+			},
+			Status: GitStarStatus{StarNumber: 42, ForkNumber: 7, UpdatedAt: updatedAt, LastRefreshRequest: "2020-05-01T10:00:00Z"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "failed", Namespace: "default"},
+			Spec:       GitStarSpec{RepoName: "no-owner"},
+			Status:     GitStarStatus{StarNumber: 42, UpdatedAt: updatedAt, FailedReason: "404 Not Found"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "odd-name", Namespace: "default"},
+			Spec:       GitStarSpec{RepoName: "/a/b/"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "repositories", Namespace: "default"},
+			Spec: GitStarSpec{
+				RepoName:          "kuri-su/kblog",
+				Repositories:      []string{"kuri-su/kblog-ui"},
+				TrackStargazers:   true,
+				StargazerLimit:    100,
+				BackfillHistory:   true,
+				TrackReleases:     true,
+				TrackActivity:     true,
+				TrackContributors: true,
+				TopContributors:   5,
+				TrackTraffic:      true,
+			},
+			Status: GitStarStatus{
+				StarNumber: 45,
+				UpdatedAt:  updatedAt,
+				Stargazers: &GitStarStargazersStatus{
+					SnapshotName: "repositories-stargazers",
+					Count:        45,
+					NewCount:     1,
+					New:          []string{"kuri-su"},
+					UpdatedAt:    updatedAt,
+				},
+				History: &GitStarHistoryStatus{
+					HistoryName:      "repositories-history",
+					Points:           12,
+					BackfilledPoints: 11,
+					Sampled:          true,
+					UpdatedAt:        updatedAt,
+				},
+				LatestRelease: &GitStarReleaseStatus{
+					RepoName:     "kuri-su/kblog",
+					TagName:      "v1.0.0",
+					PublishedAt:  updatedAt,
+					ReleaseCount: 3,
+					TagCount:     4,
+					UpdatedAt:    updatedAt,
+				},
+				Activity: &GitStarActivityStatus{
+					OpenPullRequests:    2,
+					IssuesOpened:        5,
+					MedianFirstResponse: &metav1.Duration{Duration: time.Hour},
+					Responses:           3,
+					UpdatedAt:           updatedAt,
+				},
+				Contributors: &GitStarContributorsStatus{
+					Count:     12,
+					Top:       []GitStarContributor{{Login: "kuri-su", Commits: 120}},
+					UpdatedAt: updatedAt,
+				},
+				Traffic: &GitStarTrafficStatus{
+					TrafficName:  "repositories-traffic",
+					Views:        140,
+					TopReferrers: []GitStarReferrer{{Referrer: "github.com", Views: 30, UniqueVisitors: 10}},
+					Days:         21,
+					SkippedRepos: []string{"kuri-su/kblog-ui"},
+					UpdatedAt:    updatedAt,
+				},
+				Repositories: []GitStarRepositoryStatus{
+					{RepoName: "kuri-su/kblog", StarNumber: 42, UpdatedAt: updatedAt},
+					{RepoName: "kuri-su/kblog-ui", StarNumber: 3, FailedReason: "404 Not Found"},
+				},
+			},
+		},
+	}
+	for _, in := range tests {
+		t.Run(in.Name, func(t *testing.T) {
+			hub := &v2.GitStar{}
+			if err := in.DeepCopy().ConvertTo(hub); err != nil {
+				t.Fatal(err)
+			}
+			out := &GitStar{}
+			if err := out.ConvertFrom(hub); err != nil {
+				t.Fatal(err)
+			}
+			delete(out.Annotations, ConversionDataAnnotation)
+			if len(out.Annotations) == 0 {
+				out.Annotations = nil
+			}
+			if !equality.Semantic.DeepEqual(in.Spec, out.Spec) || !equality.Semantic.DeepEqual(in.Status, out.Status) || !equality.Semantic.DeepEqual(in.ObjectMeta, out.ObjectMeta) {
+				t.Errorf("round trip of %+v gave %+v", in, out)
+			}
+		})
+	}
+}
+
+func TestConvertRepoNameWithoutOwner(t *testing.T) {
+	tests := []GitStarSpec{
+		{RepoName: "no-owner"},
+		{RepoName: "/a/b/"},
+		{RepoName: "kuri-su/"},
+		{RepoName: "kuri-su/kblog/"},
+		{},
+		{RepoName: "kuri-su/kblog", Repositories: []string{"kblog-ui"}},
+	}
+	for _, spec := range tests {
+		in := &GitStar{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "default"}, Spec: spec}
+		hub := &v2.GitStar{}
+		if err := in.DeepCopy().ConvertTo(hub); err != nil {
+			t.Fatalf("spec %+v: %v, want the legacy object converted", spec, err)
+		}
+		// the v2 object keeps the repo names as written in v1
+		if got := keptRepoNames(hub.Annotations); !equality.Semantic.DeepEqual(got, spec) {
+			t.Errorf("spec %+v: annotation of v2 = %+v, want the v1 repo names", spec, got)
+		}
+		out := &GitStar{}
+		if err := out.ConvertFrom(hub); err != nil {
+			t.Fatal(err)
+		}
+		if !equality.Semantic.DeepEqual(out.Spec, spec) || out.Annotations[ConversionDataAnnotation] != "" {
+			t.Errorf("round trip of spec %+v gave %+v, %v", spec, out.Spec, out.Annotations)
+		}
+	}
+
+	// valid repo names aren't kept
+	hub := &v2.GitStar{}
+	in := &GitStar{Spec: GitStarSpec{RepoName: "kuri-su/kblog", Repositories: []string{"kuri-su/kblog-ui"}}}
+	if err := in.ConvertTo(hub); err != nil {
+		t.Fatal(err)
+	}
+	if hub.Annotations != nil {
+		t.Errorf("annotations = %v, want none", hub.Annotations)
+	}
+}
+
+// keptRepoNames returns the repo names kept in the annotations of a converted GitStar
+func keptRepoNames(annotations map[string]string) GitStarSpec {
+	data := &conversionData{}
+	_ = json.Unmarshal([]byte(annotations[ConversionDataAnnotation]), data)
+	return GitStarSpec{RepoName: data.RepoName, Repositories: data.Repositories}
+}
+
+func TestConvertReadyTransitionTime(t *testing.T) {
+	created := metav1.NewTime(time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC))
+	updatedAt := metav1.NewTime(time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC))
+	tests := []struct {
+		status GitStarStatus
+		want   metav1.Time
+	}{
+		{GitStarStatus{StarNumber: 42, UpdatedAt: updatedAt}, updatedAt},
+		{GitStarStatus{StarNumber: 42, UpdatedAt: updatedAt, FailedReason: "502 Bad Gateway"}, updatedAt},
+		{GitStarStatus{FailedReason: "404 Not Found"}, created},
+	}
+	for _, tt := range tests {
+		in := &GitStar{
+			ObjectMeta: metav1.ObjectMeta{Name: "kblog", Namespace: "default", CreationTimestamp: created},
+			Spec:       GitStarSpec{RepoName: "kuri-su/kblog"},
+			Status:     tt.status,
+		}
+		first := &v2.GitStar{}
+		if err := in.DeepCopy().ConvertTo(first); err != nil {
+			t.Fatal(err)
+		}
+		ready := first.Status.Condition(v2.ConditionReady)
+		if ready == nil || !ready.LastTransitionTime.Equal(&tt.want) {
+			t.Errorf("status %+v: Ready = %+v, want the transition at %v", tt.status, ready, tt.want)
+		}
+
+		// converting the v1 object again, or the v1 object converted back, gives the same v2 object
+		time.Sleep(time.Millisecond)
+		again := &v2.GitStar{}
+		if err := in.DeepCopy().ConvertTo(again); err != nil {
+			t.Fatal(err)
+		}
+		spoke := &GitStar{}
+		if err := spoke.ConvertFrom(first.DeepCopy()); err != nil {
+			t.Fatal(err)
+		}
+		back := &v2.GitStar{}
+		if err := spoke.ConvertTo(back); err != nil {
+			t.Fatal(err)
+		}
+		if !equality.Semantic.DeepEqual(first, again) || !equality.Semantic.DeepEqual(first, back) {
+			t.Errorf("status %+v: conversions gave %+v, %+v and %+v, want the same object", tt.status, first, again, back)
+		}
+	}
+}
+
+func TestConvertRoundTripV2(t *testing.T) {
+	updatedAt := metav1.NewTime(time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC))
+	failedAt := metav1.NewTime(time.Date(2020, 5, 2, 10, 0, 0, 0, time.UTC))
+	in := &v2.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Name: "kblog", Namespace: "default"},
+		Spec: v2.GitStarSpec{
+			Repository: v2.RepositoryReference{Owner: "kuri-su", Name: "kblog"},
+			Provider:   v2.ProviderGitHub,
+			Schedule:   "*/30 * * * *",
+		},
+		Status: v2.GitStarStatus{
+			Metrics:   v2.GitStarMetrics{Stars: 42, Forks: 7},
+			UpdatedAt: &updatedAt,
+			Conditions: []v2.GitStarCondition{
+				{Type: v2.ConditionReady, Status: corev1.ConditionFalse, LastTransitionTime: failedAt, Reason: "RateLimited"},
+				{Type: "Backfilled", Status: corev1.ConditionTrue, LastTransitionTime: updatedAt},
+			},
+		},
+	}
+
+	spoke := &GitStar{}
+	if err := spoke.ConvertFrom(in.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	if spoke.Spec.RepoName != "kuri-su/kblog" || spoke.Status.FailedReason != "RateLimited" || spoke.Schedule() != "*/30 * * * *" {
+		t.Errorf("v1 = %+v, want the repoName, failedReason and schedule of v2", spoke)
+	}
+	out := &v2.GitStar{}
+	if err := spoke.ConvertTo(out); err != nil {
+		t.Fatal(err)
+	}
+	if !equality.Semantic.DeepEqual(in, out) {
+		t.Errorf("round trip of %+v gave %+v", in, out)
+	}
+
+	// a v1 client updating the status sets the Ready condition, the others are kept
+	spoke.Status.FailedReason = ""
+	spoke.Status.UpdatedAt = metav1.NewTime(failedAt.Add(time.Hour))
+	if err := spoke.ConvertTo(out); err != nil {
+		t.Fatal(err)
+	}
+	ready := out.Status.Condition(v2.ConditionReady)
+	if ready == nil || ready.Status != corev1.ConditionTrue || ready.Reason != v2.ReasonFetched {
+		t.Errorf("Ready = %+v, want True", ready)
+	}
+	if len(out.Status.Conditions) != 2 || out.Spec.Schedule != "*/30 * * * *" {
+		t.Errorf("v2 = %+v, want the other condition and the schedule kept", out)
+	}
+}
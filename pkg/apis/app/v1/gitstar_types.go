@@ -1,9 +1,14 @@
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// RefreshRequestedAtAnnotation requests an immediate fetch of the numbers of a GitStar, each new value, usually a
+// timestamp, is handled once and recorded in status.lastRefreshRequest
+const RefreshRequestedAtAnnotation = "gitstar.app.kuricat.com/refresh-requested-at"
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -13,6 +18,46 @@ type GitStarSpec struct {
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
 	RepoName string `json:"repoName"`
+	// Repositories are more repos of the same product, like owner/name, counted together with RepoName
+	Repositories []string `json:"repositories,omitempty"`
+	// JobTemplate overlays the defaults of the operator for the pod of the queryJob of this GitStar
+	JobTemplate *GitStarJobTemplate `json:"jobTemplate,omitempty"`
+	// TrackStargazers keeps a snapshot of the stargazers of the repos and reports the new and lost ones
+	TrackStargazers bool `json:"trackStargazers,omitempty"`
+	// StargazerLimit caps the stargazers kept per repo to the newest ones, default 5000
+	StargazerLimit int32 `json:"stargazerLimit,omitempty"`
+	// BackfillHistory reconstructs the star curve of the repos from the stargazers once, every fetch adds a live
+	// sample to it
+	BackfillHistory bool `json:"backfillHistory,omitempty"`
+	// BackfillRequestLimit caps the requests backfilling a repo, larger repos are sampled, default 50
+	BackfillRequestLimit int32 `json:"backfillRequestLimit,omitempty"`
+	// TrackReleases reports the latest release of the repos and records an Event when a new one is published
+	TrackReleases bool `json:"trackReleases,omitempty"`
+	// TrackActivity reports the pull request and issue activity of the repos
+	TrackActivity bool `json:"trackActivity,omitempty"`
+	// TrackContributors reports the contributor count of the repos and their top contributors
+	TrackContributors bool `json:"trackContributors,omitempty"`
+	// TopContributors caps the contributors reported with their commits, default 10, at most 100
+	TopContributors int32 `json:"topContributors,omitempty"`
+	// TrackTraffic keeps the daily views and clones of the repos the token has push access to, GitHub only serves
+	// the last 14 days
+	TrackTraffic bool `json:"trackTraffic,omitempty"`
+}
+
+// GitStarJobTemplate configures the pod of a queryJob, set fields replace the defaults and env vars are merged by name
+type GitStarJobTemplate struct {
+	Image              string                        `json:"image,omitempty"`
+	ImagePullPolicy    corev1.PullPolicy             `json:"imagePullPolicy,omitempty"`
+	ImagePullSecrets   []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	ServiceAccountName string                        `json:"serviceAccountName,omitempty"`
+	Resources          *corev1.ResourceRequirements  `json:"resources,omitempty"`
+	NodeSelector       map[string]string             `json:"nodeSelector,omitempty"`
+	Tolerations        []corev1.Toleration           `json:"tolerations,omitempty"`
+	// SecurityContext is the security context of the container, PodSecurityContext the one of the pod
+	SecurityContext    *corev1.SecurityContext    `json:"securityContext,omitempty"`
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// Env is added to the container, e.g. HTTPS_PROXY and NO_PROXY
+	Env []corev1.EnvVar `json:"env,omitempty"`
 }
 
 // GitStarStatus defines the observed state of GitStar
@@ -21,8 +66,159 @@ type GitStarStatus struct {
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
 	StarNumber   int64       `json:"starNumber"`
+	ForkNumber   int64       `json:"forkNumber,omitempty"`
 	UpdatedAt    metav1.Time `json:"updateAt"`
 	FailedReason string      `json:"failedReason"`
+	// LastRefreshRequest is the last handled value of the refresh-requested-at annotation
+	LastRefreshRequest string `json:"lastRefreshRequest,omitempty"`
+	// Repositories are the numbers of each repo when spec.repositories is set, StarNumber and ForkNumber are
+	// their totals and FailedReason is only set when all of them failed
+	Repositories []GitStarRepositoryStatus `json:"repositories,omitempty"`
+	// Stargazers is set when spec.trackStargazers is
+	Stargazers *GitStarStargazersStatus `json:"stargazers,omitempty"`
+	// History is set when spec.backfillHistory is
+	History *GitStarHistoryStatus `json:"history,omitempty"`
+	// LatestRelease is set when spec.trackReleases is
+	LatestRelease *GitStarReleaseStatus `json:"latestRelease,omitempty"`
+	// Activity is set when spec.trackActivity is
+	Activity *GitStarActivityStatus `json:"activity,omitempty"`
+	// Contributors is set when spec.trackContributors is
+	Contributors *GitStarContributorsStatus `json:"contributors,omitempty"`
+	// Traffic is set when spec.trackTraffic is
+	Traffic *GitStarTrafficStatus `json:"traffic,omitempty"`
+}
+
+// GitStarRepositoryStatus is the result of the last fetch of one repo of a GitStar, a failed fetch keeps the
+// previous numbers
+type GitStarRepositoryStatus struct {
+	RepoName     string      `json:"repoName"`
+	StarNumber   int64       `json:"starNumber"`
+	ForkNumber   int64       `json:"forkNumber,omitempty"`
+	UpdatedAt    metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason string      `json:"failedReason,omitempty"`
+}
+
+// GitStarActivityStatus reports the pull request and issue activity of a GitStar with spec.trackActivity, the
+// counts are the totals of the repos
+type GitStarActivityStatus struct {
+	OpenPullRequests   int64 `json:"openPullRequests"`
+	ClosedPullRequests int64 `json:"closedPullRequests"`
+	// IssuesOpened and IssuesClosed count the issues opened and closed in the last 30 days
+	IssuesOpened int64 `json:"issuesOpened"`
+	IssuesClosed int64 `json:"issuesClosed"`
+	// MedianFirstResponse is the median time to the first comment of someone else than the author, over the
+	// newest issues and pull requests of the last 30 days having one, unset when none has
+	MedianFirstResponse *metav1.Duration `json:"medianFirstResponse,omitempty"`
+	// Responses is the number of issues and pull requests MedianFirstResponse is computed from
+	Responses    int64       `json:"responses"`
+	UpdatedAt    metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason string      `json:"failedReason,omitempty"`
+}
+
+// GitStarContributorsStatus reports the contributors of a GitStar with spec.trackContributors
+type GitStarContributorsStatus struct {
+	// Count sums the contributors of the repos, a contributor of several repos counts once per repo
+	Count int64 `json:"count"`
+	// Top are the contributors with the most commits to the default branches of the repos, most commits first
+	Top          []GitStarContributor `json:"top,omitempty"`
+	UpdatedAt    metav1.Time          `json:"updatedAt,omitempty"`
+	FailedReason string               `json:"failedReason,omitempty"`
+}
+
+// GitStarContributor is a contributor with the commits summed over the repos of a GitStar
+type GitStarContributor struct {
+	Login   string `json:"login"`
+	Commits int64  `json:"commits"`
+}
+
+// GitStarTrafficStatus reports the traffic of a GitStar with spec.trackTraffic
+type GitStarTrafficStatus struct {
+	// TrafficName names the ConfigMap keeping the daily traffic of each repo
+	TrafficName string `json:"trafficName,omitempty"`
+	// Views, UniqueVisitors, Clones and UniqueCloners are the totals of the last 14 days summed over the repos
+	Views          int64 `json:"views"`
+	UniqueVisitors int64 `json:"uniqueVisitors"`
+	Clones         int64 `json:"clones"`
+	UniqueCloners  int64 `json:"uniqueCloners"`
+	// TopReferrers are the sites referring the most views in the last 14 days, most views first
+	TopReferrers []GitStarReferrer `json:"topReferrers,omitempty"`
+	// Days counts the days the ConfigMap has the traffic of
+	Days int64 `json:"days"`
+	// SkippedRepos are the repos the token has no push access to, GitHub doesn't serve their traffic
+	SkippedRepos []string    `json:"skippedRepos,omitempty"`
+	UpdatedAt    metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason string      `json:"failedReason,omitempty"`
+}
+
+// GitStarReferrer is a site referring visitors to the repos of a GitStar
+type GitStarReferrer struct {
+	Referrer       string `json:"referrer"`
+	Views          int64  `json:"views"`
+	UniqueVisitors int64  `json:"uniqueVisitors"`
+}
+
+// GitStarHistoryStatus reports the star curve of a GitStar with spec.backfillHistory
+type GitStarHistoryStatus struct {
+	// HistoryName names the ConfigMap keeping the star curve of each repo
+	HistoryName string `json:"historyName,omitempty"`
+	// Points counts the points of the curves, BackfilledPoints the ones reconstructed from the stargazers
+	Points           int64 `json:"points"`
+	BackfilledPoints int64 `json:"backfilledPoints"`
+	// Sampled is true when a curve was backfilled from a sample of the stargazers of a large repo
+	Sampled      bool        `json:"sampled,omitempty"`
+	UpdatedAt    metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason string      `json:"failedReason,omitempty"`
+}
+
+// GitStarReleaseStatus reports the releases of a GitStar with spec.trackReleases
+type GitStarReleaseStatus struct {
+	// RepoName, TagName, Name, URL and PublishedAt describe the newest release of the repos, prereleases aside
+	RepoName    string      `json:"repoName,omitempty"`
+	TagName     string      `json:"tagName,omitempty"`
+	Name        string      `json:"name,omitempty"`
+	URL         string      `json:"url,omitempty"`
+	PublishedAt metav1.Time `json:"publishedAt,omitempty"`
+	// LatestDownloadCount sums the downloads of the assets of the newest release
+	LatestDownloadCount int64 `json:"latestDownloadCount"`
+	// ReleaseCount, DownloadCount and TagCount are the totals of the repos, DownloadCount sums the downloads of
+	// the assets of all releases
+	ReleaseCount  int64       `json:"releaseCount"`
+	DownloadCount int64       `json:"downloadCount"`
+	TagCount      int64       `json:"tagCount"`
+	UpdatedAt     metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason  string      `json:"failedReason,omitempty"`
+}
+
+// GitStarStargazersStatus reports the stargazers of a GitStar with spec.trackStargazers
+type GitStarStargazersStatus struct {
+	// SnapshotName names the ConfigMap keeping the stargazers of the last fetch
+	SnapshotName string `json:"snapshotName,omitempty"`
+	// Count is the number of stargazers in the snapshot
+	Count int64 `json:"count"`
+	// Truncated is true when a repo has more stargazers than spec.stargazerLimit or its share of the 10000
+	// stargazers kept per GitStar, only the newest are kept
+	Truncated bool `json:"truncated,omitempty"`
+	// NewCount and LostCount are the stargazers gained and lost since the previous fetch, New and Lost list the
+	// first of their logins
+	NewCount     int64       `json:"newCount"`
+	New          []string    `json:"new,omitempty"`
+	LostCount    int64       `json:"lostCount"`
+	Lost         []string    `json:"lost,omitempty"`
+	UpdatedAt    metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason string      `json:"failedReason,omitempty"`
+}
+
+// RepoNames returns spec.repoName followed by spec.repositories without duplicates
+func (in *GitStarSpec) RepoNames() []string {
+	repoNames := make([]string, 0, 1+len(in.Repositories))
+	seen := make(map[string]bool, 1+len(in.Repositories))
+	for _, repoName := range append([]string{in.RepoName}, in.Repositories...) {
+		if !seen[repoName] {
+			seen[repoName] = true
+			repoNames = append(repoNames, repoName)
+		}
+	}
+	return repoNames
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitStarGroupSpec defines the desired state of GitStarGroup
+type GitStarGroupSpec struct {
+	// Selector selects the member GitStars in the namespace of the group, an empty selector selects all of them
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// TopN is the number of members listed in status.topMembers, default 5
+	TopN int32 `json:"topN,omitempty"`
+	// DeltaPeriod is the length of the period the deltas are computed over, default 24h
+	DeltaPeriod *metav1.Duration `json:"deltaPeriod,omitempty"`
+}
+
+// GitStarGroupMember is a member GitStar of a group
+type GitStarGroupMember struct {
+	Name       string `json:"name"`
+	RepoName   string `json:"repoName"`
+	StarNumber int64  `json:"starNumber"`
+	ForkNumber int64  `json:"forkNumber"`
+}
+
+// GitStarGroupStatus defines the observed state of GitStarGroup
+type GitStarGroupStatus struct {
+	// Members is the number of selected GitStars
+	Members int32 `json:"members"`
+	// FailedMembers is the number of selected GitStars whose last fetch failed
+	FailedMembers int32 `json:"failedMembers,omitempty"`
+	TotalStars    int64 `json:"totalStars"`
+	TotalForks    int64 `json:"totalForks"`
+	// StarsDelta and ForksDelta are the changes of the totals since PeriodStartedAt
+	StarsDelta int64 `json:"starsDelta"`
+	ForksDelta int64 `json:"forksDelta"`
+	// PeriodStartedAt, PeriodStartStars and PeriodStartForks record the totals at the start of the current period
+	PeriodStartedAt  metav1.Time `json:"periodStartedAt,omitempty"`
+	PeriodStartStars int64       `json:"periodStartStars,omitempty"`
+	PeriodStartForks int64       `json:"periodStartForks,omitempty"`
+	// TopMembers are the members with the most stars
+	TopMembers   []GitStarGroupMember `json:"topMembers,omitempty"`
+	UpdatedAt    metav1.Time          `json:"updatedAt,omitempty"`
+	FailedReason string               `json:"failedReason,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitStarGroup is the Schema for the gitstargroups API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=gitstargroups,scope=Namespaced
+type GitStarGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitStarGroupSpec   `json:"spec,omitempty"`
+	Status GitStarGroupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitStarGroupList contains a list of GitStarGroup
+type GitStarGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitStarGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitStarGroup{}, &GitStarGroupList{})
+}
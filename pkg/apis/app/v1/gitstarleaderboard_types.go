@@ -0,0 +1,88 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// LeaderboardOrderByStars ranks the GitStars by their star number
+	LeaderboardOrderByStars = "stars"
+	// LeaderboardOrderByGrowth ranks the GitStars by the stars gained in the growth window
+	LeaderboardOrderByGrowth = "growth"
+)
+
+// GitStarLeaderboardSpec defines the desired state of GitStarLeaderboard
+type GitStarLeaderboardSpec struct {
+	// Selector selects the ranked GitStars in the namespace of the leaderboard, an empty selector selects all of them
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// OrderBy is "stars" or "growth", default "stars"
+	OrderBy string `json:"orderBy,omitempty"`
+	// GrowthWindow is the window the growth is computed over, default 168h
+	GrowthWindow *metav1.Duration `json:"growthWindow,omitempty"`
+	// Period is the length of the period the rank changes are computed over, default 24h
+	Period *metav1.Duration `json:"period,omitempty"`
+}
+
+// GitStarLeaderboardEntry is the rank of a GitStar
+type GitStarLeaderboardEntry struct {
+	Rank       int32  `json:"rank"`
+	Name       string `json:"name"`
+	RepoName   string `json:"repoName"`
+	StarNumber int64  `json:"starNumber"`
+	// Growth is the number of stars gained in the growth window
+	Growth int64 `json:"growth"`
+	// RankChange is positive when the GitStar climbed since the start of the period, 0 for new entries
+	RankChange int32 `json:"rankChange"`
+	// GapToNext is the difference of the ordered value to the entry ranked right above, 0 for the first entry
+	GapToNext int64 `json:"gapToNext"`
+}
+
+// StarSample is the star number of a GitStar at a point in time
+type StarSample struct {
+	Time       metav1.Time `json:"time"`
+	StarNumber int64       `json:"starNumber"`
+}
+
+// GitStarLeaderboardHistory holds the samples of a GitStar inside the growth window
+type GitStarLeaderboardHistory struct {
+	Name    string       `json:"name"`
+	Samples []StarSample `json:"samples,omitempty"`
+}
+
+// GitStarLeaderboardStatus defines the observed state of GitStarLeaderboard
+type GitStarLeaderboardStatus struct {
+	Entries []GitStarLeaderboardEntry `json:"entries,omitempty"`
+	// PeriodStartedAt and PeriodStartRanks record the ranks at the start of the current period
+	PeriodStartedAt  metav1.Time      `json:"periodStartedAt,omitempty"`
+	PeriodStartRanks map[string]int32 `json:"periodStartRanks,omitempty"`
+	// History keeps the samples the growth is computed from, up to 48 per GitStar spread over the growth window
+	History      []GitStarLeaderboardHistory `json:"history,omitempty"`
+	UpdatedAt    metav1.Time                 `json:"updatedAt,omitempty"`
+	FailedReason string                      `json:"failedReason,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitStarLeaderboard is the Schema for the gitstarleaderboards API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=gitstarleaderboards,scope=Namespaced
+type GitStarLeaderboard struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitStarLeaderboardSpec   `json:"spec,omitempty"`
+	Status GitStarLeaderboardStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitStarLeaderboardList contains a list of GitStarLeaderboard
+type GitStarLeaderboardList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitStarLeaderboard `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitStarLeaderboard{}, &GitStarLeaderboardList{})
+}
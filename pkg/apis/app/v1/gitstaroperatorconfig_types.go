@@ -0,0 +1,75 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CredentialsReference references the key of a ConfigMap holding the GitHub OAuth token
+type CredentialsReference struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+// GitStarOperatorSettings are the settings of the GitStars, unset fields keep the defaults
+type GitStarOperatorSettings struct {
+	// Schedule is the cron schedule of the queryJobs
+	Schedule string `json:"schedule,omitempty"`
+	// Credentials references the GitHub OAuth token, unset fields keep the defaults
+	Credentials *CredentialsReference `json:"credentials,omitempty"`
+	// JobTemplate configures the queryJob pods, spec.jobTemplate of a GitStar overlays it
+	JobTemplate                *GitStarJobTemplate `json:"jobTemplate,omitempty"`
+	SuccessfulJobsHistoryLimit *int32              `json:"successfulJobsHistoryLimit,omitempty"`
+	FailedJobsHistoryLimit     *int32              `json:"failedJobsHistoryLimit,omitempty"`
+}
+
+// GitStarNamespaceOverride overlays the settings for the GitStars of a namespace
+type GitStarNamespaceOverride struct {
+	Namespace               string `json:"namespace"`
+	GitStarOperatorSettings `json:",inline"`
+}
+
+// GitStarOperatorConfigSpec defines the desired state of GitStarOperatorConfig
+type GitStarOperatorConfigSpec struct {
+	GitStarOperatorSettings `json:",inline"`
+	// NamespaceOverrides overlay the settings for the GitStars of some namespaces
+	NamespaceOverrides []GitStarNamespaceOverride `json:"namespaceOverrides,omitempty"`
+	// MetricsPort and OperatorMetricsPort are the ports of the metrics, they are read when the operator starts
+	MetricsPort         *int32 `json:"metricsPort,omitempty"`
+	OperatorMetricsPort *int32 `json:"operatorMetricsPort,omitempty"`
+}
+
+// GitStarOperatorConfigStatus defines the observed state of GitStarOperatorConfig
+type GitStarOperatorConfigStatus struct {
+	// ObservedGeneration is the generation of the spec the operator has loaded
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+	FailedReason       string `json:"failedReason,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitStarOperatorConfig is the Schema for the gitstaroperatorconfigs API, the operator reads the one named
+// by its --config-name flag and reloads it when it changes
+// +genclient:nonNamespaced
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=gitstaroperatorconfigs,scope=Cluster
+type GitStarOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitStarOperatorConfigSpec   `json:"spec,omitempty"`
+	Status GitStarOperatorConfigStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitStarOperatorConfigList contains a list of GitStarOperatorConfig
+type GitStarOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitStarOperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitStarOperatorConfig{}, &GitStarOperatorConfigList{})
+}
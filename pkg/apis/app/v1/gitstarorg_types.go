@@ -0,0 +1,72 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitStarOrgSpec defines the desired state of GitStarOrg
+type GitStarOrgSpec struct {
+	// Owner is the GitHub organization or user whose repositories are tracked
+	Owner string `json:"owner"`
+	// Include is a regular expression matched against the repository name, only matching repositories are tracked
+	Include string `json:"include,omitempty"`
+	// Exclude is a regular expression matched against the repository name, matching repositories are skipped
+	Exclude string `json:"exclude,omitempty"`
+	// MinStars skips repositories with fewer stars
+	MinStars int64 `json:"minStars,omitempty"`
+	// IncludeForks tracks forked repositories as well
+	IncludeForks bool `json:"includeForks,omitempty"`
+	// IncludeArchived tracks archived repositories as well
+	IncludeArchived bool `json:"includeArchived,omitempty"`
+	// SyncPeriod is the interval between two listings of the owner's repositories, default 1h
+	SyncPeriod *metav1.Duration `json:"syncPeriod,omitempty"`
+}
+
+// GitStarOrgStatus defines the observed state of GitStarOrg
+type GitStarOrgStatus struct {
+	// TotalRepos is the number of repositories owned by the owner
+	TotalRepos int32 `json:"totalRepos"`
+	// MatchedRepos is the number of repositories that passed the filters
+	MatchedRepos int32 `json:"matchedRepos"`
+	// TrackedRepos is the number of GitStar objects owned by this GitStarOrg
+	TrackedRepos int32 `json:"trackedRepos"`
+	// TotalStars is the sum of stars of the matched repositories
+	TotalStars int64 `json:"totalStars"`
+	// ConflictingGitStars are the GitStars not owned by this GitStarOrg with the name of a matched repository, the
+	// repository isn't tracked until they are deleted
+	ConflictingGitStars []string `json:"conflictingGitStars,omitempty"`
+	// ObservedGeneration is the generation of the spec the status was computed for
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+	UpdatedAt          metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason       string      `json:"failedReason,omitempty"`
+	// FailedAt is the time of the last failed sync, the next one is delayed by a backoff
+	FailedAt metav1.Time `json:"failedAt,omitempty"`
+	// Failures is the number of syncs failed in a row, the backoff doubles with each one up to the sync period
+	Failures int32 `json:"failures,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitStarOrg is the Schema for the gitstarorgs API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=gitstarorgs,scope=Namespaced
+type GitStarOrg struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitStarOrgSpec   `json:"spec,omitempty"`
+	Status GitStarOrgStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitStarOrgList contains a list of GitStarOrg
+type GitStarOrgList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitStarOrg `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitStarOrg{}, &GitStarOrgList{})
+}
@@ -5,31 +5,702 @@
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsReference) DeepCopyInto(out *CredentialsReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsReference.
+func (in *CredentialsReference) DeepCopy() *CredentialsReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitStar) DeepCopyInto(out *GitStar) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStar.
+func (in *GitStar) DeepCopy() *GitStar {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStar)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitStar) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarActivityStatus) DeepCopyInto(out *GitStarActivityStatus) {
+	*out = *in
+	if in.MedianFirstResponse != nil {
+		in, out := &in.MedianFirstResponse, &out.MedianFirstResponse
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarActivityStatus.
+func (in *GitStarActivityStatus) DeepCopy() *GitStarActivityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarActivityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarContributor) DeepCopyInto(out *GitStarContributor) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarContributor.
+func (in *GitStarContributor) DeepCopy() *GitStarContributor {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarContributor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarContributorsStatus) DeepCopyInto(out *GitStarContributorsStatus) {
+	*out = *in
+	if in.Top != nil {
+		in, out := &in.Top, &out.Top
+		*out = make([]GitStarContributor, len(*in))
+		copy(*out, *in)
+	}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarContributorsStatus.
+func (in *GitStarContributorsStatus) DeepCopy() *GitStarContributorsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarContributorsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarGroup) DeepCopyInto(out *GitStarGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarGroup.
+func (in *GitStarGroup) DeepCopy() *GitStarGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitStarGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarGroupList) DeepCopyInto(out *GitStarGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GitStarGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarGroupList.
+func (in *GitStarGroupList) DeepCopy() *GitStarGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitStarGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarGroupMember) DeepCopyInto(out *GitStarGroupMember) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarGroupMember.
+func (in *GitStarGroupMember) DeepCopy() *GitStarGroupMember {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarGroupMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarGroupSpec) DeepCopyInto(out *GitStarGroupSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeltaPeriod != nil {
+		in, out := &in.DeltaPeriod, &out.DeltaPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarGroupSpec.
+func (in *GitStarGroupSpec) DeepCopy() *GitStarGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarGroupStatus) DeepCopyInto(out *GitStarGroupStatus) {
+	*out = *in
+	in.PeriodStartedAt.DeepCopyInto(&out.PeriodStartedAt)
+	if in.TopMembers != nil {
+		in, out := &in.TopMembers, &out.TopMembers
+		*out = make([]GitStarGroupMember, len(*in))
+		copy(*out, *in)
+	}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarGroupStatus.
+func (in *GitStarGroupStatus) DeepCopy() *GitStarGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarHistoryStatus) DeepCopyInto(out *GitStarHistoryStatus) {
+	*out = *in
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarHistoryStatus.
+func (in *GitStarHistoryStatus) DeepCopy() *GitStarHistoryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarHistoryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarJobTemplate) DeepCopyInto(out *GitStarJobTemplate) {
+	*out = *in
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(corev1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSecurityContext != nil {
+		in, out := &in.PodSecurityContext, &out.PodSecurityContext
+		*out = new(corev1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarJobTemplate.
+func (in *GitStarJobTemplate) DeepCopy() *GitStarJobTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarJobTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarLeaderboard) DeepCopyInto(out *GitStarLeaderboard) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarLeaderboard.
+func (in *GitStarLeaderboard) DeepCopy() *GitStarLeaderboard {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarLeaderboard)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitStarLeaderboard) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarLeaderboardEntry) DeepCopyInto(out *GitStarLeaderboardEntry) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarLeaderboardEntry.
+func (in *GitStarLeaderboardEntry) DeepCopy() *GitStarLeaderboardEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarLeaderboardEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarLeaderboardHistory) DeepCopyInto(out *GitStarLeaderboardHistory) {
+	*out = *in
+	if in.Samples != nil {
+		in, out := &in.Samples, &out.Samples
+		*out = make([]StarSample, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarLeaderboardHistory.
+func (in *GitStarLeaderboardHistory) DeepCopy() *GitStarLeaderboardHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarLeaderboardHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarLeaderboardList) DeepCopyInto(out *GitStarLeaderboardList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GitStarLeaderboard, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarLeaderboardList.
+func (in *GitStarLeaderboardList) DeepCopy() *GitStarLeaderboardList {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarLeaderboardList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitStarLeaderboardList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarLeaderboardSpec) DeepCopyInto(out *GitStarLeaderboardSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GrowthWindow != nil {
+		in, out := &in.GrowthWindow, &out.GrowthWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Period != nil {
+		in, out := &in.Period, &out.Period
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarLeaderboardSpec.
+func (in *GitStarLeaderboardSpec) DeepCopy() *GitStarLeaderboardSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarLeaderboardSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarLeaderboardStatus) DeepCopyInto(out *GitStarLeaderboardStatus) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]GitStarLeaderboardEntry, len(*in))
+		copy(*out, *in)
+	}
+	in.PeriodStartedAt.DeepCopyInto(&out.PeriodStartedAt)
+	if in.PeriodStartRanks != nil {
+		in, out := &in.PeriodStartRanks, &out.PeriodStartRanks
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]GitStarLeaderboardHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarLeaderboardStatus.
+func (in *GitStarLeaderboardStatus) DeepCopy() *GitStarLeaderboardStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarLeaderboardStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarList) DeepCopyInto(out *GitStarList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GitStar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarList.
+func (in *GitStarList) DeepCopy() *GitStarList {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitStarList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarNamespaceOverride) DeepCopyInto(out *GitStarNamespaceOverride) {
+	*out = *in
+	in.GitStarOperatorSettings.DeepCopyInto(&out.GitStarOperatorSettings)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarNamespaceOverride.
+func (in *GitStarNamespaceOverride) DeepCopy() *GitStarNamespaceOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarNamespaceOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarOperatorConfig) DeepCopyInto(out *GitStarOperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarOperatorConfig.
+func (in *GitStarOperatorConfig) DeepCopy() *GitStarOperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarOperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitStarOperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarOperatorConfigList) DeepCopyInto(out *GitStarOperatorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GitStarOperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarOperatorConfigList.
+func (in *GitStarOperatorConfigList) DeepCopy() *GitStarOperatorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarOperatorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitStarOperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarOperatorConfigSpec) DeepCopyInto(out *GitStarOperatorConfigSpec) {
+	*out = *in
+	in.GitStarOperatorSettings.DeepCopyInto(&out.GitStarOperatorSettings)
+	if in.NamespaceOverrides != nil {
+		in, out := &in.NamespaceOverrides, &out.NamespaceOverrides
+		*out = make([]GitStarNamespaceOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricsPort != nil {
+		in, out := &in.MetricsPort, &out.MetricsPort
+		*out = new(int32)
+		**out = **in
+	}
+	if in.OperatorMetricsPort != nil {
+		in, out := &in.OperatorMetricsPort, &out.OperatorMetricsPort
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarOperatorConfigSpec.
+func (in *GitStarOperatorConfigSpec) DeepCopy() *GitStarOperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarOperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarOperatorConfigStatus) DeepCopyInto(out *GitStarOperatorConfigStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarOperatorConfigStatus.
+func (in *GitStarOperatorConfigStatus) DeepCopy() *GitStarOperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarOperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarOperatorSettings) DeepCopyInto(out *GitStarOperatorSettings) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(CredentialsReference)
+		**out = **in
+	}
+	if in.JobTemplate != nil {
+		in, out := &in.JobTemplate, &out.JobTemplate
+		*out = new(GitStarJobTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SuccessfulJobsHistoryLimit != nil {
+		in, out := &in.SuccessfulJobsHistoryLimit, &out.SuccessfulJobsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedJobsHistoryLimit != nil {
+		in, out := &in.FailedJobsHistoryLimit, &out.FailedJobsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarOperatorSettings.
+func (in *GitStarOperatorSettings) DeepCopy() *GitStarOperatorSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarOperatorSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarOrg) DeepCopyInto(out *GitStarOrg) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStar.
-func (in *GitStar) DeepCopy() *GitStar {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarOrg.
+func (in *GitStarOrg) DeepCopy() *GitStarOrg {
 	if in == nil {
 		return nil
 	}
-	out := new(GitStar)
+	out := new(GitStarOrg)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *GitStar) DeepCopyObject() runtime.Object {
+func (in *GitStarOrg) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -37,13 +708,13 @@ func (in *GitStar) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitStarList) DeepCopyInto(out *GitStarList) {
+func (in *GitStarOrgList) DeepCopyInto(out *GitStarOrgList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]GitStar, len(*in))
+		*out = make([]GitStarOrg, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -51,27 +722,132 @@ func (in *GitStarList) DeepCopyInto(out *GitStarList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarList.
-func (in *GitStarList) DeepCopy() *GitStarList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarOrgList.
+func (in *GitStarOrgList) DeepCopy() *GitStarOrgList {
 	if in == nil {
 		return nil
 	}
-	out := new(GitStarList)
+	out := new(GitStarOrgList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *GitStarList) DeepCopyObject() runtime.Object {
+func (in *GitStarOrgList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarOrgSpec) DeepCopyInto(out *GitStarOrgSpec) {
+	*out = *in
+	if in.SyncPeriod != nil {
+		in, out := &in.SyncPeriod, &out.SyncPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarOrgSpec.
+func (in *GitStarOrgSpec) DeepCopy() *GitStarOrgSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarOrgSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarOrgStatus) DeepCopyInto(out *GitStarOrgStatus) {
+	*out = *in
+	if in.ConflictingGitStars != nil {
+		in, out := &in.ConflictingGitStars, &out.ConflictingGitStars
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	in.FailedAt.DeepCopyInto(&out.FailedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarOrgStatus.
+func (in *GitStarOrgStatus) DeepCopy() *GitStarOrgStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarOrgStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarReferrer) DeepCopyInto(out *GitStarReferrer) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarReferrer.
+func (in *GitStarReferrer) DeepCopy() *GitStarReferrer {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarReferrer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarReleaseStatus) DeepCopyInto(out *GitStarReleaseStatus) {
+	*out = *in
+	in.PublishedAt.DeepCopyInto(&out.PublishedAt)
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarReleaseStatus.
+func (in *GitStarReleaseStatus) DeepCopy() *GitStarReleaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarReleaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarRepositoryStatus) DeepCopyInto(out *GitStarRepositoryStatus) {
+	*out = *in
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarRepositoryStatus.
+func (in *GitStarRepositoryStatus) DeepCopy() *GitStarRepositoryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarRepositoryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitStarSpec) DeepCopyInto(out *GitStarSpec) {
 	*out = *in
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.JobTemplate != nil {
+		in, out := &in.JobTemplate, &out.JobTemplate
+		*out = new(GitStarJobTemplate)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -85,10 +861,74 @@ func (in *GitStarSpec) DeepCopy() *GitStarSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarStargazersStatus) DeepCopyInto(out *GitStarStargazersStatus) {
+	*out = *in
+	if in.New != nil {
+		in, out := &in.New, &out.New
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Lost != nil {
+		in, out := &in.Lost, &out.Lost
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarStargazersStatus.
+func (in *GitStarStargazersStatus) DeepCopy() *GitStarStargazersStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarStargazersStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitStarStatus) DeepCopyInto(out *GitStarStatus) {
 	*out = *in
 	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]GitStarRepositoryStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Stargazers != nil {
+		in, out := &in.Stargazers, &out.Stargazers
+		*out = new(GitStarStargazersStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = new(GitStarHistoryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LatestRelease != nil {
+		in, out := &in.LatestRelease, &out.LatestRelease
+		*out = new(GitStarReleaseStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Activity != nil {
+		in, out := &in.Activity, &out.Activity
+		*out = new(GitStarActivityStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Contributors != nil {
+		in, out := &in.Contributors, &out.Contributors
+		*out = new(GitStarContributorsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		*out = new(GitStarTrafficStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -101,3 +941,47 @@ func (in *GitStarStatus) DeepCopy() *GitStarStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitStarTrafficStatus) DeepCopyInto(out *GitStarTrafficStatus) {
+	*out = *in
+	if in.TopReferrers != nil {
+		in, out := &in.TopReferrers, &out.TopReferrers
+		*out = make([]GitStarReferrer, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkippedRepos != nil {
+		in, out := &in.SkippedRepos, &out.SkippedRepos
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitStarTrafficStatus.
+func (in *GitStarTrafficStatus) DeepCopy() *GitStarTrafficStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitStarTrafficStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StarSample) DeepCopyInto(out *StarSample) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StarSample.
+func (in *StarSample) DeepCopy() *StarSample {
+	if in == nil {
+		return nil
+	}
+	out := new(StarSample)
+	in.DeepCopyInto(out)
+	return out
+}
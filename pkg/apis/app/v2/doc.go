@@ -0,0 +1,4 @@
+// Package v2 contains API Schema definitions for the app v2 API group
+// +k8s:deepcopy-gen=package,register
+// +groupName=app.kuricat.com
+package v2
@@ -0,0 +1,285 @@
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ProviderGitHub is the only supported provider of repositories
+	ProviderGitHub = "github"
+
+	// ConditionReady is True when the last fetch of the metrics succeeded
+	ConditionReady = "Ready"
+
+	// ReasonFetched and ReasonFetchFailed are the reasons of the Ready condition
+	ReasonFetched     = "Fetched"
+	ReasonFetchFailed = "FetchFailed"
+)
+
+// RepositoryReference references a repository by its owner and name, like kuri-su/kblog
+type RepositoryReference struct {
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+}
+
+// GitStarSpec defines the desired state of GitStar
+type GitStarSpec struct {
+	Repository RepositoryReference `json:"repository"`
+	// Repositories are more repos of the same product, counted together with Repository
+	Repositories []RepositoryReference `json:"repositories,omitempty"`
+	// Provider hosts the repository, default "github"
+	Provider string `json:"provider,omitempty"`
+	// Schedule overrides the cron schedule of the queryJob set by the GitStarOperatorConfig
+	Schedule string `json:"schedule,omitempty"`
+	// JobTemplate overlays the defaults of the operator for the pod of the queryJob of this GitStar
+	JobTemplate *GitStarJobTemplate `json:"jobTemplate,omitempty"`
+	// TrackStargazers keeps a snapshot of the stargazers of the repos and reports the new and lost ones
+	TrackStargazers bool `json:"trackStargazers,omitempty"`
+	// StargazerLimit caps the stargazers kept per repo to the newest ones, default 5000
+	StargazerLimit int32 `json:"stargazerLimit,omitempty"`
+	// BackfillHistory reconstructs the star curve of the repos from the stargazers once, every fetch adds a live
+	// sample to it
+	BackfillHistory bool `json:"backfillHistory,omitempty"`
+	// BackfillRequestLimit caps the requests backfilling a repo, larger repos are sampled, default 50
+	BackfillRequestLimit int32 `json:"backfillRequestLimit,omitempty"`
+	// TrackReleases reports the latest release of the repos and records an Event when a new one is published
+	TrackReleases bool `json:"trackReleases,omitempty"`
+	// TrackActivity reports the pull request and issue activity of the repos
+	TrackActivity bool `json:"trackActivity,omitempty"`
+	// TrackContributors reports the contributor count of the repos and their top contributors
+	TrackContributors bool `json:"trackContributors,omitempty"`
+	// TopContributors caps the contributors reported with their commits, default 10, at most 100
+	TopContributors int32 `json:"topContributors,omitempty"`
+	// TrackTraffic keeps the daily views and clones of the repos the token has push access to, GitHub only serves
+	// the last 14 days
+	TrackTraffic bool `json:"trackTraffic,omitempty"`
+}
+
+// GitStarJobTemplate configures the pod of a queryJob, set fields replace the defaults and env vars are merged by name
+type GitStarJobTemplate struct {
+	Image              string                        `json:"image,omitempty"`
+	ImagePullPolicy    corev1.PullPolicy             `json:"imagePullPolicy,omitempty"`
+	ImagePullSecrets   []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	ServiceAccountName string                        `json:"serviceAccountName,omitempty"`
+	Resources          *corev1.ResourceRequirements  `json:"resources,omitempty"`
+	NodeSelector       map[string]string             `json:"nodeSelector,omitempty"`
+	Tolerations        []corev1.Toleration           `json:"tolerations,omitempty"`
+	// SecurityContext is the security context of the container, PodSecurityContext the one of the pod
+	SecurityContext    *corev1.SecurityContext    `json:"securityContext,omitempty"`
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// Env is added to the container, e.g. HTTPS_PROXY and NO_PROXY
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// GitStarMetrics are the numbers of a repository
+type GitStarMetrics struct {
+	Stars int64 `json:"stars"`
+	Forks int64 `json:"forks"`
+}
+
+// GitStarCondition is an observation of the state of a GitStar
+type GitStarCondition struct {
+	Type               string                 `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+}
+
+// GitStarStatus defines the observed state of GitStar
+type GitStarStatus struct {
+	Metrics GitStarMetrics `json:"metrics"`
+	// UpdatedAt is the time of the last successful fetch of the metrics
+	UpdatedAt  *metav1.Time       `json:"updatedAt,omitempty"`
+	Conditions []GitStarCondition `json:"conditions,omitempty"`
+	// LastRefreshRequest is the last handled value of the refresh-requested-at annotation
+	LastRefreshRequest string `json:"lastRefreshRequest,omitempty"`
+	// Repositories are the metrics of each repo when spec.repositories is set, Metrics are their totals and
+	// the Ready condition is only False when all of them failed
+	Repositories []GitStarRepositoryStatus `json:"repositories,omitempty"`
+	// Stargazers is set when spec.trackStargazers is
+	Stargazers *GitStarStargazersStatus `json:"stargazers,omitempty"`
+	// History is set when spec.backfillHistory is
+	History *GitStarHistoryStatus `json:"history,omitempty"`
+	// LatestRelease is set when spec.trackReleases is
+	LatestRelease *GitStarReleaseStatus `json:"latestRelease,omitempty"`
+	// Activity is set when spec.trackActivity is
+	Activity *GitStarActivityStatus `json:"activity,omitempty"`
+	// Contributors is set when spec.trackContributors is
+	Contributors *GitStarContributorsStatus `json:"contributors,omitempty"`
+	// Traffic is set when spec.trackTraffic is
+	Traffic *GitStarTrafficStatus `json:"traffic,omitempty"`
+}
+
+// GitStarRepositoryStatus is the result of the last fetch of one repo of a GitStar, a failed fetch keeps the
+// previous metrics
+type GitStarRepositoryStatus struct {
+	Repository   RepositoryReference `json:"repository"`
+	Metrics      GitStarMetrics      `json:"metrics"`
+	UpdatedAt    *metav1.Time        `json:"updatedAt,omitempty"`
+	FailedReason string              `json:"failedReason,omitempty"`
+}
+
+// GitStarActivityStatus reports the pull request and issue activity of a GitStar with spec.trackActivity, the
+// counts are the totals of the repos
+type GitStarActivityStatus struct {
+	OpenPullRequests   int64 `json:"openPullRequests"`
+	ClosedPullRequests int64 `json:"closedPullRequests"`
+	// IssuesOpened and IssuesClosed count the issues opened and closed in the last 30 days
+	IssuesOpened int64 `json:"issuesOpened"`
+	IssuesClosed int64 `json:"issuesClosed"`
+	// MedianFirstResponse is the median time to the first comment of someone else than the author, over the
+	// newest issues and pull requests of the last 30 days having one, unset when none has
+	MedianFirstResponse *metav1.Duration `json:"medianFirstResponse,omitempty"`
+	// Responses is the number of issues and pull requests MedianFirstResponse is computed from
+	Responses    int64       `json:"responses"`
+	UpdatedAt    metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason string      `json:"failedReason,omitempty"`
+}
+
+// GitStarContributorsStatus reports the contributors of a GitStar with spec.trackContributors
+type GitStarContributorsStatus struct {
+	// Count sums the contributors of the repos, a contributor of several repos counts once per repo
+	Count int64 `json:"count"`
+	// Top are the contributors with the most commits to the default branches of the repos, most commits first
+	Top          []GitStarContributor `json:"top,omitempty"`
+	UpdatedAt    metav1.Time          `json:"updatedAt,omitempty"`
+	FailedReason string               `json:"failedReason,omitempty"`
+}
+
+// GitStarContributor is a contributor with the commits summed over the repos of a GitStar
+type GitStarContributor struct {
+	Login   string `json:"login"`
+	Commits int64  `json:"commits"`
+}
+
+// GitStarTrafficStatus reports the traffic of a GitStar with spec.trackTraffic
+type GitStarTrafficStatus struct {
+	// TrafficName names the ConfigMap keeping the daily traffic of each repo
+	TrafficName string `json:"trafficName,omitempty"`
+	// Views, UniqueVisitors, Clones and UniqueCloners are the totals of the last 14 days summed over the repos
+	Views          int64 `json:"views"`
+	UniqueVisitors int64 `json:"uniqueVisitors"`
+	Clones         int64 `json:"clones"`
+	UniqueCloners  int64 `json:"uniqueCloners"`
+	// TopReferrers are the sites referring the most views in the last 14 days, most views first
+	TopReferrers []GitStarReferrer `json:"topReferrers,omitempty"`
+	// Days counts the days the ConfigMap has the traffic of
+	Days int64 `json:"days"`
+	// SkippedRepos are the repos the token has no push access to, GitHub doesn't serve their traffic
+	SkippedRepos []string    `json:"skippedRepos,omitempty"`
+	UpdatedAt    metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason string      `json:"failedReason,omitempty"`
+}
+
+// GitStarReferrer is a site referring visitors to the repos of a GitStar
+type GitStarReferrer struct {
+	Referrer       string `json:"referrer"`
+	Views          int64  `json:"views"`
+	UniqueVisitors int64  `json:"uniqueVisitors"`
+}
+
+// GitStarHistoryStatus reports the star curve of a GitStar with spec.backfillHistory
+type GitStarHistoryStatus struct {
+	// HistoryName names the ConfigMap keeping the star curve of each repo
+	HistoryName string `json:"historyName,omitempty"`
+	// Points counts the points of the curves, BackfilledPoints the ones reconstructed from the stargazers
+	Points           int64 `json:"points"`
+	BackfilledPoints int64 `json:"backfilledPoints"`
+	// Sampled is true when a curve was backfilled from a sample of the stargazers of a large repo
+	Sampled      bool        `json:"sampled,omitempty"`
+	UpdatedAt    metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason string      `json:"failedReason,omitempty"`
+}
+
+// GitStarReleaseStatus reports the releases of a GitStar with spec.trackReleases
+type GitStarReleaseStatus struct {
+	// RepoName, TagName, Name, URL and PublishedAt describe the newest release of the repos, prereleases aside
+	RepoName    string      `json:"repoName,omitempty"`
+	TagName     string      `json:"tagName,omitempty"`
+	Name        string      `json:"name,omitempty"`
+	URL         string      `json:"url,omitempty"`
+	PublishedAt metav1.Time `json:"publishedAt,omitempty"`
+	// LatestDownloadCount sums the downloads of the assets of the newest release
+	LatestDownloadCount int64 `json:"latestDownloadCount"`
+	// ReleaseCount, DownloadCount and TagCount are the totals of the repos, DownloadCount sums the downloads of
+	// the assets of all releases
+	ReleaseCount  int64       `json:"releaseCount"`
+	DownloadCount int64       `json:"downloadCount"`
+	TagCount      int64       `json:"tagCount"`
+	UpdatedAt     metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason  string      `json:"failedReason,omitempty"`
+}
+
+// GitStarStargazersStatus reports the stargazers of a GitStar with spec.trackStargazers
+type GitStarStargazersStatus struct {
+	// SnapshotName names the ConfigMap keeping the stargazers of the last fetch
+	SnapshotName string `json:"snapshotName,omitempty"`
+	// Count is the number of stargazers in the snapshot
+	Count int64 `json:"count"`
+	// Truncated is true when a repo has more stargazers than spec.stargazerLimit or its share of the 10000
+	// stargazers kept per GitStar, only the newest are kept
+	Truncated bool `json:"truncated,omitempty"`
+	// NewCount and LostCount are the stargazers gained and lost since the previous fetch, New and Lost list the
+	// first of their logins
+	NewCount     int64       `json:"newCount"`
+	New          []string    `json:"new,omitempty"`
+	LostCount    int64       `json:"lostCount"`
+	Lost         []string    `json:"lost,omitempty"`
+	UpdatedAt    metav1.Time `json:"updatedAt,omitempty"`
+	FailedReason string      `json:"failedReason,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitStar is the Schema for the gitstars API, the storage version of GitStars
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:path=gitstars,scope=Namespaced
+type GitStar struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitStarSpec   `json:"spec,omitempty"`
+	Status GitStarStatus `json:"status,omitempty"`
+}
+
+// Hub marks v2 as the version the other versions of GitStar convert to and from
+func (*GitStar) Hub() {}
+
+// Condition returns the condition of type t, nil when it isn't set
+func (in *GitStarStatus) Condition(t string) *GitStarCondition {
+	for i := range in.Conditions {
+		if in.Conditions[i].Type == t {
+			return &in.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition sets the condition of its type, the transition time is kept unless the status changes
+func (in *GitStarStatus) SetCondition(condition GitStarCondition) {
+	existing := in.Condition(condition.Type)
+	if existing == nil {
+		in.Conditions = append(in.Conditions, condition)
+		return
+	}
+	if existing.Status == condition.Status {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	}
+	*existing = condition
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitStarList contains a list of GitStar
+type GitStarList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitStar `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitStar{}, &GitStarList{})
+}
@@ -0,0 +1,10 @@
+package controller
+
+import (
+	"gitstar-operator/pkg/controller/gitstarleaderboard"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, gitstarleaderboard.Add)
+}
@@ -3,12 +3,12 @@ package gitstar
 import (
 	"context"
 
-	batchv1 "k8s.io/api/batch/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	appv1 "gitstar-operator/pkg/apis/app/v1"
 	"gitstar-operator/pkg/gitOperation"
+	"gitstar-operator/pkg/operatorconfig"
 	"gitstar-operator/pkg/resource"
 
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -25,26 +25,58 @@ import (
 
 var log = logf.Log.WithName("controller_gitstar")
 
+var (
+	// RefreshWorkers is the number of GitStars fetched in the background at the same time, the pool never has fewer
+	// workers than MaxConcurrentReconciles
+	RefreshWorkers = gitOperation.DefaultRefreshWorkers
+	// RefreshTimeout is the time allowed for the fetch of the repo numbers of one GitStar
+	RefreshTimeout = gitOperation.DefaultRefreshTimeout
+)
+
 /**
 * USER ACTION REQUIRED: This is a scaffold file intended for the user to modify with their own Controller
 * business logic.  Delete these comments after modifying this file.*
  */
 
 // Add creates a new GitStar Controller and adds it to the Manager. The Manager will set fields on the Controller
-// and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+// and Start it when the Manager is Started. The options, like MaxConcurrentReconciles, are shared by all Controllers.
+func Add(mgr manager.Manager, options controller.Options) error {
+	pool := gitOperation.NewRefreshPool(newRefreshRunner(mgr), refreshWorkers(options), RefreshTimeout)
+	if err := mgr.Add(pool); err != nil {
+		return err
+	}
+	return add(mgr, newReconciler(mgr, pool), options)
+}
+
+// refreshWorkers returns the workers of the refresh pool, enough to keep up with the reconcilers enqueuing refreshes
+func refreshWorkers(options controller.Options) int {
+	if RefreshWorkers < options.MaxConcurrentReconciles {
+		return options.MaxConcurrentReconciles
+	}
+	return RefreshWorkers
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileGitStar{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager, pool *gitOperation.RefreshPool) reconcile.Reconciler {
+	return &ReconcileGitStar{client: mgr.GetClient(), scheme: mgr.GetScheme(), refresh: pool.Enqueue}
+}
+
+// newRefreshRunner creates a Runner with the clients of the manager, it reads from the apiserver instead of the
+// cache so the status update isn't based on a stale GitStar and the token ConfigMap is readable outside the
+// watched namespaces
+func newRefreshRunner(mgr manager.Manager) *gitOperation.Runner {
+	return gitOperation.NewRunner(&client.DelegatingClient{
+		Reader:       mgr.GetAPIReader(),
+		Writer:       mgr.GetClient(),
+		StatusClient: mgr.GetClient(),
+	})
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, options controller.Options) error {
 	// Create a new controller
-	c, err := controller.New("gitstar-controller", mgr, controller.Options{Reconciler: r})
+	options.Reconciler = r
+	c, err := controller.New("gitstar-controller", mgr, options)
 	if err != nil {
 		return err
 	}
@@ -55,6 +87,14 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to the GitStarOperatorConfig, it updates the CronJobs of all GitStars
+	err = c.Watch(&source.Kind{Type: &appv1.GitStarOperatorConfig{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: gitStarsOfConfig(mgr.GetClient()),
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -67,6 +107,8 @@ type ReconcileGitStar struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
+	// refresh schedules the fetch of the star number of a new or refresh requested GitStar, it must not block
+	refresh func(namespace, name string)
 }
 
 // Reconcile reads that state of the cluster for a GitStar object and makes changes based on the state read
@@ -81,6 +123,7 @@ func (r *ReconcileGitStar) Reconcile(request reconcile.Request) (reconcile.Resul
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// gitStar was delete
+			exportMetrics(request.NamespacedName, nil)
 			err := resource.DeleteCronJob(&appv1.GitStar{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: request.Namespace,
@@ -97,33 +140,91 @@ func (r *ReconcileGitStar) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
-	cronJob := resource.NewCronJobForCR(instance)
+	// the status is written by the queryJob, every update reconciles
+	exportMetrics(request.NamespacedName, &instance.Status)
+
+	settings := operatorconfig.SettingsFor(context.TODO(), r.client, instance.Namespace)
+	cronJob := resource.NewCronJobForCR(instance, &settings)
 	// Set GitStar instance as the owner and controller
 	if err := controllerutil.SetControllerReference(instance, cronJob, r.scheme); err != nil {
 		return reconcile.Result{}, err
 	}
 
-	found := &batchv1.CronJob{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cronJob.Name, Namespace: cronJob.Namespace}, found)
+	found, err := resource.GetCronJob(context.TODO(), r.client, types.NamespacedName{Name: cronJob.Name, Namespace: cronJob.Namespace})
 
+	created := false
 	if err != nil && errors.IsNotFound(err) {
 		reqLogger.Info("Creating a new CronJob", "CronJob.Namespace", cronJob.Namespace, "CronJob.Name", cronJob.Name)
-		err := r.client.Create(context.TODO(), cronJob)
+		err := resource.CreateCronJob(context.TODO(), r.client, cronJob)
 		if err != nil {
 			reqLogger.Error(err, "create CronJob of GetStar failed!")
 			return reconcile.Result{}, nil
 		}
 		reqLogger.Info("create CronJob of GetStar success!")
-		gitOperation.Run(instance.Namespace, instance.Name)
-
-		return reconcile.Result{}, nil
+		created = true
 	} else if err != nil {
 		// Error reading the object - requeue the request.
 		reqLogger.Error(err, "get cronJob object failed!")
 		return reconcile.Result{}, err
+	} else if found.Annotations[resource.SpecHashAnnotation] != cronJob.Annotations[resource.SpecHashAnnotation] {
+		// the job template of the GitStar, the GitStarOperatorConfig or the defaults of the operator changed, or
+		// the CronJob was written in batch/v1beta1 and batch/v1 is available
+		reqLogger.Info("Updating the CronJob", "CronJob.Namespace", found.Namespace, "CronJob.Name", found.Name)
+		if found.Annotations == nil {
+			found.Annotations = map[string]string{}
+		}
+		found.Annotations[resource.SpecHashAnnotation] = cronJob.Annotations[resource.SpecHashAnnotation]
+		found.Spec.Schedule = cronJob.Spec.Schedule
+		found.Spec.SuccessfulJobsHistoryLimit = cronJob.Spec.SuccessfulJobsHistoryLimit
+		found.Spec.FailedJobsHistoryLimit = cronJob.Spec.FailedJobsHistoryLimit
+		found.Spec.JobTemplate = cronJob.Spec.JobTemplate
+		if err := resource.UpdateCronJob(context.TODO(), r.client, found); err != nil {
+			reqLogger.Error(err, "update CronJob of GetStar failed!")
+			return reconcile.Result{}, err
+		}
+	}
+
+	// a new value of the refresh annotation is recorded before the fetch, so it is handled once even if the fetch fails
+	refreshRequest := instance.Annotations[appv1.RefreshRequestedAtAnnotation]
+	refreshRequested := refreshRequest != "" && refreshRequest != instance.Status.LastRefreshRequest
+	if refreshRequested {
+		reqLogger.Info("Refresh requested", "RefreshRequestedAt", refreshRequest)
+		instance.Status.LastRefreshRequest = refreshRequest
+		if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+			reqLogger.Error(err, "record refresh request of GitStar failed!")
+			return reconcile.Result{}, err
+		}
+	}
+
+	if created || refreshRequested {
+		r.refresh(instance.Namespace, instance.Name)
+		return reconcile.Result{}, nil
 	}
 
 	// Pod already exists - don't requeue
 	reqLogger.Info("Skip reconcile: CronJob already exists", "CronJob.Namespace", found.Namespace, "CronJob.Name", found.Name)
 	return reconcile.Result{}, nil
 }
+
+// gitStarsOfConfig maps the GitStarOperatorConfig of the operator to all GitStars
+func gitStarsOfConfig(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		if a.Meta.GetName() != operatorconfig.Name {
+			return nil
+		}
+
+		gitStars := &appv1.GitStarList{}
+		if err := c.List(context.TODO(), gitStars); err != nil {
+			log.Error(err, "list GitStars failed!")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(gitStars.Items))
+		for _, gitStar := range gitStars.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: gitStar.Namespace,
+				Name:      gitStar.Name,
+			}})
+		}
+		return requests
+	}
+}
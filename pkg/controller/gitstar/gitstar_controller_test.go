@@ -0,0 +1,562 @@
+package gitstar
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+	"sigs.k8s.io/yaml"
+
+	"gitstar-operator/pkg/apis"
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+	appv2 "gitstar-operator/pkg/apis/app/v2"
+	"gitstar-operator/pkg/fakegithub"
+	"gitstar-operator/pkg/gitOperation"
+	"gitstar-operator/pkg/resource"
+)
+
+const (
+	testTimeout  = 30 * time.Second
+	testInterval = 100 * time.Millisecond
+	testToken    = "0123456789012345678901234567890123456789"
+)
+
+var (
+	// cfg is the config of the local kube-apiserver, nil when the envtest binaries are missing
+	cfg          *rest.Config
+	envtestError error
+)
+
+// TestMain starts a local kube-apiserver and etcd from $KUBEBUILDER_ASSETS (default /usr/local/kubebuilder/bin)
+// for the integration tests, they are skipped when the binaries can't be started
+func TestMain(m *testing.M) {
+	crdPaths, gitStarCRD, err := testCRDs(filepath.Join("..", "..", "..", "deploy", "crds"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	testEnv := &envtest.Environment{CRDDirectoryPaths: crdPaths}
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	cfg, envtestError = testEnv.Start()
+	if envtestError != nil {
+		os.Exit(m.Run())
+	}
+	stop := make(chan struct{})
+	err = installGitStarCRD(gitStarCRD, &testEnv.WebhookInstallOptions, stop)
+	code := 1
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	} else {
+		code = m.Run()
+	}
+	close(stop)
+	_ = testEnv.Stop()
+	os.Exit(code)
+}
+
+// testCRDs returns the CRD files in dir except the one of GitStars, which is installed by installGitStarCRD
+func testCRDs(dir string) ([]string, *unstructured.Unstructured, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var paths []string
+	var gitStarCRD *unstructured.Unstructured
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name())
+		if !strings.HasSuffix(file.Name(), "_gitstars_crd.yaml") {
+			paths = append(paths, path)
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		gitStarCRD = &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(data, &gitStarCRD.Object); err != nil {
+			return nil, nil, err
+		}
+	}
+	if gitStarCRD == nil {
+		return nil, nil, fmt.Errorf("CRD of GitStars not found in %s", dir)
+	}
+	return paths, gitStarCRD, nil
+}
+
+// installGitStarCRD installs the CRD of GitStars with its conversion webhook served on the local address and
+// certificate of envtest, by a manager registering it like the operator does, until stop is closed
+func installGitStarCRD(crd *unstructured.Unstructured, options *envtest.WebhookInstallOptions, stop <-chan struct{}) error {
+	caBundle, err := ioutil.ReadFile(filepath.Join(options.LocalServingCertDir, "tls.crt"))
+	if err != nil {
+		return err
+	}
+	// the local kube-apiserver can't reach the service of the webhook, it calls the local address instead
+	hostPort := net.JoinHostPort(options.LocalServingHost, strconv.Itoa(options.LocalServingPort))
+	clientConfig := map[string]interface{}{
+		"url":      "https://" + hostPort + "/convert",
+		"caBundle": base64.StdEncoding.EncodeToString(caBundle),
+	}
+	if err := unstructured.SetNestedMap(crd.Object, clientConfig, "spec", "conversion", "webhook", "clientConfig"); err != nil {
+		return err
+	}
+
+	mgr, err := manager.New(cfg, manager.Options{
+		MetricsBindAddress: "0",
+		Host:               options.LocalServingHost,
+		Port:               options.LocalServingPort,
+		CertDir:            options.LocalServingCertDir,
+	})
+	if err != nil {
+		return err
+	}
+	if err := builder.WebhookManagedBy(mgr).For(&appv1.GitStar{}).Complete(); err != nil {
+		return err
+	}
+	go func() {
+		if err := mgr.Start(stop); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+
+	if _, err := envtest.InstallCRDs(cfg, envtest.CRDInstallOptions{CRDs: []runtime.Object{crd}}); err != nil {
+		return err
+	}
+	c, err := client.New(cfg, client.Options{})
+	if err != nil {
+		return err
+	}
+	// v1 GitStars are served once the webhook converts them
+	return wait.PollImmediate(testInterval, testTimeout, func() (bool, error) {
+		return c.List(context.TODO(), &appv1.GitStarList{}) == nil, nil
+	})
+}
+
+// testCluster is a manager running the GitStar controller against the local kube-apiserver and a fake GitHub
+type testCluster struct {
+	client    client.Client
+	github    *fakegithub.Server
+	namespace string
+	stop      chan struct{}
+}
+
+func startTestCluster(t *testing.T) *testCluster {
+	if envtestError != nil {
+		t.Skipf("envtest binaries are not available: %v", envtestError)
+	}
+
+	github := fakegithub.NewServer()
+	factory, err := gitOperation.GitHubFetcherFactoryForURL(github.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := newRefreshRunner(mgr)
+	runner.NewFetcher = factory
+	pool := gitOperation.NewRefreshPool(runner, 2, 10*time.Second)
+	if err := mgr.Add(pool); err != nil {
+		t.Fatal(err)
+	}
+	r := &ReconcileGitStar{client: mgr.GetClient(), scheme: mgr.GetScheme(), refresh: pool.Enqueue}
+	if err := add(mgr, r, controller.Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := &testCluster{
+		client:    mgr.GetClient(),
+		github:    github,
+		namespace: fmt.Sprintf("test-%d", time.Now().UnixNano()),
+		stop:      make(chan struct{}),
+	}
+	go func() {
+		if err := mgr.Start(tc.stop); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tc.namespace}}
+	if err := tc.client.Create(context.TODO(), ns); err != nil {
+		t.Fatal(err)
+	}
+	return tc
+}
+
+func (tc *testCluster) close() {
+	close(tc.stop)
+	tc.github.Close()
+}
+
+func (tc *testCluster) createGitStar(t *testing.T, name, repoName string) *appv1.GitStar {
+	gitStar := &appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: tc.namespace, Name: name},
+		Spec:       appv1.GitStarSpec{RepoName: repoName},
+	}
+	if err := tc.client.Create(context.TODO(), gitStar); err != nil {
+		t.Fatal(err)
+	}
+	return gitStar
+}
+
+// waitGitStar waits until the GitStar named name satisfies cond
+func (tc *testCluster) waitGitStar(t *testing.T, name string, cond func(*appv1.GitStar) bool) *appv1.GitStar {
+	gitStar := &appv1.GitStar{}
+	err := wait.PollImmediate(testInterval, testTimeout, func() (bool, error) {
+		err := tc.client.Get(context.TODO(), types.NamespacedName{Namespace: tc.namespace, Name: name}, gitStar)
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return err == nil && cond(gitStar), err
+	})
+	if err != nil {
+		t.Fatalf("wait GitStar %s: %v, last status %+v", name, err, gitStar.Status)
+	}
+	return gitStar
+}
+
+func (tc *testCluster) getCronJob(name string) (*batchv1.CronJob, error) {
+	cronJob := &batchv1.CronJob{}
+	err := tc.client.Get(context.TODO(), types.NamespacedName{Namespace: tc.namespace, Name: name}, cronJob)
+	return cronJob, err
+}
+
+func TestReconcileCreate(t *testing.T) {
+	tc := startTestCluster(t)
+	defer tc.close()
+	tc.github.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42, Forks: 3})
+
+	tc.createGitStar(t, "kblog", "kuri-su/kblog")
+	gitStar := tc.waitGitStar(t, "kblog", func(g *appv1.GitStar) bool { return g.Status.StarNumber == 42 })
+	if gitStar.Status.ForkNumber != 3 || gitStar.Status.FailedReason != "" {
+		t.Errorf("status = %+v, want 3 forks and no failedReason", gitStar.Status)
+	}
+
+	cronJob, err := tc.getCronJob(resource.GenerateCronJobName(gitStar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := metav1.GetControllerOf(cronJob)
+	if owner == nil || owner.Kind != "GitStar" || owner.Name != gitStar.Name || owner.UID != gitStar.UID {
+		t.Errorf("controller of CronJob = %+v, want GitStar %s/%s", owner, gitStar.Name, gitStar.UID)
+	}
+	env := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Env
+	if len(env) != 2 || env[0].Value != gitStar.Name || env[1].Value != gitStar.Namespace {
+		t.Errorf("env of CronJob = %+v, want the GitStar name and namespace", env)
+	}
+}
+
+func TestReconcileUpdate(t *testing.T) {
+	tc := startTestCluster(t)
+	defer tc.close()
+	tc.github.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42})
+
+	tc.createGitStar(t, "kblog", "kuri-su/kblog")
+	gitStar := tc.waitGitStar(t, "kblog", func(g *appv1.GitStar) bool { return g.Status.StarNumber == 42 })
+
+	gitStar.Labels = map[string]string{"team": "blog"}
+	if err := tc.client.Update(context.TODO(), gitStar); err != nil {
+		t.Fatal(err)
+	}
+	tc.waitGitStar(t, "kblog", func(g *appv1.GitStar) bool { return g.Labels["team"] == "blog" })
+
+	// the existing CronJob is kept, no second one is created
+	err := wait.PollImmediate(testInterval, 2*time.Second, func() (bool, error) {
+		cronJobs := &batchv1.CronJobList{}
+		if err := tc.client.List(context.TODO(), cronJobs, client.InNamespace(tc.namespace)); err != nil {
+			return false, err
+		}
+		if len(cronJobs.Items) != 1 {
+			return false, fmt.Errorf("got %d CronJobs, want 1", len(cronJobs.Items))
+		}
+		return false, nil
+	})
+	if err != wait.ErrWaitTimeout {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcileJobTemplate(t *testing.T) {
+	tc := startTestCluster(t)
+	defer tc.close()
+	tc.github.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42})
+
+	tc.createGitStar(t, "kblog", "kuri-su/kblog")
+	gitStar := tc.waitGitStar(t, "kblog", func(g *appv1.GitStar) bool { return g.Status.StarNumber == 42 })
+
+	gitStar.Spec.JobTemplate = &appv1.GitStarJobTemplate{Image: "registry.example.com/gitstar-queryjob:v1"}
+	if err := tc.client.Update(context.TODO(), gitStar); err != nil {
+		t.Fatal(err)
+	}
+	err := wait.PollImmediate(testInterval, testTimeout, func() (bool, error) {
+		cronJob, err := tc.getCronJob(resource.GenerateCronJobName(gitStar))
+		if err != nil {
+			return false, err
+		}
+		return cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image == gitStar.Spec.JobTemplate.Image, nil
+	})
+	if err != nil {
+		t.Fatalf("wait CronJob updated: %v", err)
+	}
+}
+
+func TestReconcileDelete(t *testing.T) {
+	tc := startTestCluster(t)
+	defer tc.close()
+	tc.github.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42})
+
+	gitStar := tc.createGitStar(t, "kblog", "kuri-su/kblog")
+	tc.waitGitStar(t, "kblog", func(g *appv1.GitStar) bool { return g.Status.StarNumber == 42 })
+	if err := tc.client.Delete(context.TODO(), gitStar); err != nil {
+		t.Fatal(err)
+	}
+
+	// envtest runs no garbage collector, the CronJob must be deleted by the controller
+	err := wait.PollImmediate(testInterval, testTimeout, func() (bool, error) {
+		_, err := tc.getCronJob(resource.GenerateCronJobName(gitStar))
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		t.Fatalf("wait CronJob deleted: %v", err)
+	}
+}
+
+func TestReconcileRefreshRequested(t *testing.T) {
+	tc := startTestCluster(t)
+	defer tc.close()
+	tc.github.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42})
+
+	tc.createGitStar(t, "kblog", "kuri-su/kblog")
+	gitStar := tc.waitGitStar(t, "kblog", func(g *appv1.GitStar) bool { return g.Status.StarNumber == 42 })
+
+	tc.github.SetStars("kuri-su/kblog", 43)
+	gitStar.Annotations = map[string]string{appv1.RefreshRequestedAtAnnotation: "2020-04-20T12:00:00Z"}
+	if err := tc.client.Update(context.TODO(), gitStar); err != nil {
+		t.Fatal(err)
+	}
+	gitStar = tc.waitGitStar(t, "kblog", func(g *appv1.GitStar) bool { return g.Status.StarNumber == 43 })
+	if gitStar.Status.LastRefreshRequest != "2020-04-20T12:00:00Z" {
+		t.Errorf("lastRefreshRequest = %q, want the annotation", gitStar.Status.LastRefreshRequest)
+	}
+
+	// the handled request is not fetched again
+	tc.github.SetStars("kuri-su/kblog", 44)
+	gitStar.Labels = map[string]string{"team": "blog"}
+	if err := tc.client.Update(context.TODO(), gitStar); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Second)
+	tc.waitGitStar(t, "kblog", func(g *appv1.GitStar) bool { return g.Status.StarNumber == 43 })
+}
+
+func TestReconcileToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    *corev1.ConfigMap
+		wantAuth bool
+	}{
+		{
+			name: "token missing",
+		},
+		{
+			name: "token configured",
+			token: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: gitOperation.GitHubOAuthTokenCMNameSpace,
+					Name:      gitOperation.GitHubOAuthTokenCMName,
+				},
+				Data: map[string]string{gitOperation.GitHubOAuthTokenCMFileName: testToken},
+			},
+			wantAuth: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := startTestCluster(t)
+			defer tc.close()
+			tc.github.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42})
+			if tt.token != nil {
+				if err := tc.client.Create(context.TODO(), tt.token); err != nil {
+					t.Fatal(err)
+				}
+				defer tc.client.Delete(context.TODO(), tt.token)
+			}
+
+			tc.createGitStar(t, "kblog", "kuri-su/kblog")
+			tc.waitGitStar(t, "kblog", func(g *appv1.GitStar) bool { return g.Status.StarNumber == 42 })
+
+			for _, req := range tc.github.Requests() {
+				auth := req.Header.Get("Authorization")
+				if tt.wantAuth && auth != "Bearer "+testToken {
+					t.Errorf("Authorization = %q, want the configured token", auth)
+				}
+				if !tt.wantAuth && auth != "" {
+					t.Errorf("Authorization = %q, want anonymous request", auth)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileRepoNotFound(t *testing.T) {
+	tc := startTestCluster(t)
+	defer tc.close()
+
+	tc.createGitStar(t, "missing", "kuri-su/missing")
+	gitStar := tc.waitGitStar(t, "missing", func(g *appv1.GitStar) bool { return g.Status.FailedReason != "" })
+	if !strings.Contains(gitStar.Status.FailedReason, "404") || gitStar.Status.StarNumber != 0 {
+		t.Errorf("status = %+v, want a 404 failedReason", gitStar.Status)
+	}
+
+	// the CronJob is created anyway so the repo is retried later
+	if _, err := tc.getCronJob(resource.GenerateCronJobName(gitStar)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConversionWebhook(t *testing.T) {
+	tc := startTestCluster(t)
+	defer tc.close()
+	// read around the cache of the manager, it only watches v1
+	c, err := client.New(cfg, client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := func(name string) types.NamespacedName {
+		return types.NamespacedName{Namespace: tc.namespace, Name: name}
+	}
+
+	// a v1 GitStar is stored as v2
+	tc.createGitStar(t, "kblog", "kuri-su/kblog")
+	stored := &appv2.GitStar{}
+	if err := c.Get(context.TODO(), key("kblog"), stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Spec.Repository != (appv2.RepositoryReference{Owner: "kuri-su", Name: "kblog"}) {
+		t.Errorf("repository = %+v, want kuri-su/kblog", stored.Spec.Repository)
+	}
+
+	// a v2 GitStar is served to v1 clients with the fields v1 doesn't have in the annotation
+	err = c.Create(context.TODO(), &appv2.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: tc.namespace, Name: "kblog-ui"},
+		Spec: appv2.GitStarSpec{
+			Repository: appv2.RepositoryReference{Owner: "kuri-su", Name: "kblog-ui"},
+			Schedule:   "*/30 * * * *",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitStar := &appv1.GitStar{}
+	if err := c.Get(context.TODO(), key("kblog-ui"), gitStar); err != nil {
+		t.Fatal(err)
+	}
+	if gitStar.Spec.RepoName != "kuri-su/kblog-ui" || gitStar.Schedule() != "*/30 * * * *" {
+		t.Errorf("v1 GitStar = %+v, want the repo and schedule of v2", gitStar)
+	}
+
+	// the v1 schema rejects a repo name without owner
+	gitStar = &appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: tc.namespace, Name: "no-owner"},
+		Spec:       appv1.GitStarSpec{RepoName: "kblog"},
+	}
+	if err := c.Create(context.TODO(), gitStar); !errors.IsInvalid(err) {
+		t.Errorf("create GitStar without owner: %v, want invalid", err)
+	}
+}
+
+func TestConversionReview(t *testing.T) {
+	wh := &conversion.Webhook{}
+	if err := wh.InjectScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	for _, repoName := range []string{"kuri-su/kblog", "no-owner"} {
+		object, err := json.Marshal(&appv1.GitStar{
+			TypeMeta:   metav1.TypeMeta{APIVersion: appv1.SchemeGroupVersion.String(), Kind: "GitStar"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kblog", UID: "1"},
+			Spec:       appv1.GitStarSpec{RepoName: repoName},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the kube-apiserver sends apiextensions.k8s.io/v1 reviews first, they are answered in the same version
+		review, err := json.Marshal(&apiextensionsv1.ConversionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "ConversionReview"},
+			Request: &apiextensionsv1.ConversionRequest{
+				UID:               "review",
+				DesiredAPIVersion: appv2.SchemeGroupVersion.String(),
+				Objects:           []runtime.RawExtension{{Raw: object}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		wh.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/convert", bytes.NewReader(review)))
+
+		got := &apiextensionsv1.ConversionReview{}
+		if err := json.NewDecoder(w.Body).Decode(got); err != nil {
+			t.Fatal(err)
+		}
+		if got.APIVersion != apiextensionsv1.SchemeGroupVersion.String() || got.Response == nil || got.Response.UID != "review" ||
+			got.Response.Result.Status != metav1.StatusSuccess || len(got.Response.ConvertedObjects) != 1 {
+			t.Fatalf("repo %s: review = %+v, want a successful v1 response", repoName, got)
+		}
+		converted := &appv2.GitStar{}
+		if err := json.Unmarshal(got.Response.ConvertedObjects[0].Raw, converted); err != nil {
+			t.Fatal(err)
+		}
+		if converted.APIVersion != appv2.SchemeGroupVersion.String() || converted.Spec.Repository.Name == "" {
+			t.Errorf("repo %s: converted %+v, want a v2 GitStar", repoName, converted)
+		}
+	}
+}
+
+func TestRefreshWorkers(t *testing.T) {
+	defer func(workers int) { RefreshWorkers = workers }(RefreshWorkers)
+	RefreshWorkers = 4
+	if got := refreshWorkers(controller.Options{MaxConcurrentReconciles: 1}); got != 4 {
+		t.Errorf("workers = %d, want --refresh-workers 4", got)
+	}
+	if got := refreshWorkers(controller.Options{MaxConcurrentReconciles: 8}); got != 8 {
+		t.Errorf("workers = %d, want --max-concurrent-reconciles 8", got)
+	}
+}
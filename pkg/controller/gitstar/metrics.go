@@ -0,0 +1,135 @@
+package gitstar
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/metrics"
+)
+
+var (
+	// seriesLabels remembers the series of every GitStar set by replaceSeries in each gauge, like the ones of its top
+	// contributors, so stale ones can be deleted
+	seriesMu     sync.Mutex
+	seriesLabels = map[*prometheus.GaugeVec]map[types.NamespacedName][]prometheus.Labels{}
+)
+
+// exportMetrics sets the series of a GitStar from its status, a nil status deletes them
+func exportMetrics(gitStar types.NamespacedName, status *appv1.GitStarStatus) {
+	var activity *appv1.GitStarActivityStatus
+	var contributors *appv1.GitStarContributorsStatus
+	var traffic *appv1.GitStarTrafficStatus
+	if status != nil {
+		activity = status.Activity
+		contributors = status.Contributors
+		traffic = status.Traffic
+	}
+	exportActivity(gitStar, activity)
+	exportContributors(gitStar, contributors)
+	exportTraffic(gitStar, traffic)
+}
+
+// exportActivity sets the activity series of a GitStar, they are deleted without activity
+func exportActivity(gitStar types.NamespacedName, activity *appv1.GitStarActivityStatus) {
+	l := prometheus.Labels{"namespace": gitStar.Namespace, "gitstar": gitStar.Name}
+	pullRequests := map[string]int64{}
+	issues := map[string]int64{}
+	if activity != nil {
+		pullRequests = map[string]int64{"open": activity.OpenPullRequests, "closed": activity.ClosedPullRequests}
+		issues = map[string]int64{"opened": activity.IssuesOpened, "closed": activity.IssuesClosed}
+	}
+
+	for _, state := range []string{"open", "closed"} {
+		setOrDelete(metrics.GitStarPullRequests, with(l, "state", state), pullRequests, state)
+	}
+	for _, event := range []string{"opened", "closed"} {
+		setOrDelete(metrics.GitStarIssuesLast30Days, with(l, "event", event), issues, event)
+	}
+	if activity != nil && activity.MedianFirstResponse != nil {
+		metrics.GitStarFirstResponseSeconds.With(l).Set(activity.MedianFirstResponse.Seconds())
+	} else {
+		metrics.GitStarFirstResponseSeconds.Delete(l)
+	}
+}
+
+// exportContributors sets the contributor series of a GitStar and deletes the ones of contributors which left the
+// top, they are all deleted without contributors
+func exportContributors(gitStar types.NamespacedName, contributors *appv1.GitStarContributorsStatus) {
+	l := prometheus.Labels{"namespace": gitStar.Namespace, "gitstar": gitStar.Name}
+	commits := map[string]int64{}
+	if contributors != nil {
+		metrics.GitStarContributors.With(l).Set(float64(contributors.Count))
+		for _, contributor := range contributors.Top {
+			commits[contributor.Login] = contributor.Commits
+		}
+	} else {
+		metrics.GitStarContributors.Delete(l)
+	}
+	replaceSeries(metrics.GitStarTopContributorCommits, gitStar, "login", commits)
+}
+
+// exportTraffic sets the traffic series of a GitStar and deletes the ones of referrers which left the top, they are
+// all deleted without traffic
+func exportTraffic(gitStar types.NamespacedName, traffic *appv1.GitStarTrafficStatus) {
+	l := prometheus.Labels{"namespace": gitStar.Namespace, "gitstar": gitStar.Name}
+	views := map[string]int64{}
+	clones := map[string]int64{}
+	referrers := map[string]int64{}
+	if traffic != nil {
+		views = map[string]int64{"total": traffic.Views, "unique": traffic.UniqueVisitors}
+		clones = map[string]int64{"total": traffic.Clones, "unique": traffic.UniqueCloners}
+		for _, referrer := range traffic.TopReferrers {
+			referrers[referrer.Referrer] = referrer.Views
+		}
+	}
+
+	for _, count := range []string{"total", "unique"} {
+		setOrDelete(metrics.GitStarTrafficViews, with(l, "count", count), views, count)
+		setOrDelete(metrics.GitStarTrafficClones, with(l, "count", count), clones, count)
+	}
+	replaceSeries(metrics.GitStarTrafficReferrerViews, gitStar, "referrer", referrers)
+}
+
+// replaceSeries sets the series of a GitStar in gauge to values by the value of the label name, and deletes the
+// series it set before which aren't in values
+func replaceSeries(gauge *prometheus.GaugeVec, gitStar types.NamespacedName, name string, values map[string]int64) {
+	seriesMu.Lock()
+	defer seriesMu.Unlock()
+
+	if seriesLabels[gauge] == nil {
+		seriesLabels[gauge] = map[types.NamespacedName][]prometheus.Labels{}
+	}
+	for _, stale := range seriesLabels[gauge][gitStar] {
+		gauge.Delete(stale)
+	}
+	delete(seriesLabels[gauge], gitStar)
+
+	l := prometheus.Labels{"namespace": gitStar.Namespace, "gitstar": gitStar.Name}
+	for value, n := range values {
+		series := with(l, name, value)
+		gauge.With(series).Set(float64(n))
+		seriesLabels[gauge][gitStar] = append(seriesLabels[gauge][gitStar], series)
+	}
+}
+
+// setOrDelete sets the series l of gauge to values[key], it deletes the series when key isn't in values
+func setOrDelete(gauge *prometheus.GaugeVec, l prometheus.Labels, values map[string]int64, key string) {
+	value, ok := values[key]
+	if !ok {
+		gauge.Delete(l)
+		return
+	}
+	gauge.With(l).Set(float64(value))
+}
+
+// with returns a copy of l with the label name set to value
+func with(l prometheus.Labels, name, value string) prometheus.Labels {
+	labels := prometheus.Labels{name: value}
+	for k, v := range l {
+		labels[k] = v
+	}
+	return labels
+}
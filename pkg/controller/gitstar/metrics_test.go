@@ -0,0 +1,107 @@
+package gitstar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/metrics"
+)
+
+func TestExportMetrics(t *testing.T) {
+	key := types.NamespacedName{Namespace: "default", Name: "metrics-kblog"}
+	l := prometheus.Labels{"namespace": key.Namespace, "gitstar": key.Name}
+
+	exportMetrics(key, &appv1.GitStarStatus{Activity: &appv1.GitStarActivityStatus{
+		OpenPullRequests:    3,
+		ClosedPullRequests:  10,
+		IssuesOpened:        4,
+		IssuesClosed:        2,
+		MedianFirstResponse: &metav1.Duration{Duration: time.Hour},
+	}})
+	if got := testutil.ToFloat64(metrics.GitStarPullRequests.With(with(l, "state", "open"))); got != 3 {
+		t.Errorf("open pull requests = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(metrics.GitStarIssuesLast30Days.With(with(l, "event", "closed"))); got != 2 {
+		t.Errorf("closed issues = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.GitStarFirstResponseSeconds.With(l)); got != 3600 {
+		t.Errorf("first response = %v, want 3600", got)
+	}
+
+	// a deleted GitStar deletes its series
+	exportMetrics(key, nil)
+	if metrics.GitStarPullRequests.Delete(with(l, "state", "open")) || metrics.GitStarFirstResponseSeconds.Delete(l) {
+		t.Error("series of the deleted GitStar are still exported")
+	}
+}
+
+func TestExportContributors(t *testing.T) {
+	key := types.NamespacedName{Namespace: "default", Name: "metrics-contributors"}
+	l := prometheus.Labels{"namespace": key.Namespace, "gitstar": key.Name}
+
+	exportMetrics(key, &appv1.GitStarStatus{Contributors: &appv1.GitStarContributorsStatus{
+		Count: 12,
+		Top:   []appv1.GitStarContributor{{Login: "kuri-su", Commits: 120}, {Login: "bob", Commits: 35}},
+	}})
+	if got := testutil.ToFloat64(metrics.GitStarContributors.With(l)); got != 12 {
+		t.Errorf("contributors = %v, want 12", got)
+	}
+	if got := testutil.ToFloat64(metrics.GitStarTopContributorCommits.With(with(l, "login", "bob"))); got != 35 {
+		t.Errorf("commits of bob = %v, want 35", got)
+	}
+
+	// bob left the top
+	exportMetrics(key, &appv1.GitStarStatus{Contributors: &appv1.GitStarContributorsStatus{
+		Count: 13,
+		Top:   []appv1.GitStarContributor{{Login: "kuri-su", Commits: 121}},
+	}})
+	if metrics.GitStarTopContributorCommits.Delete(with(l, "login", "bob")) {
+		t.Error("series of bob is still exported")
+	}
+
+	exportMetrics(key, nil)
+	if metrics.GitStarContributors.Delete(l) || metrics.GitStarTopContributorCommits.Delete(with(l, "login", "kuri-su")) {
+		t.Error("series of the deleted GitStar are still exported")
+	}
+}
+
+func TestExportTraffic(t *testing.T) {
+	key := types.NamespacedName{Namespace: "default", Name: "metrics-traffic"}
+	l := prometheus.Labels{"namespace": key.Namespace, "gitstar": key.Name}
+
+	exportMetrics(key, &appv1.GitStarStatus{Traffic: &appv1.GitStarTrafficStatus{
+		Views:          140,
+		UniqueVisitors: 28,
+		Clones:         21,
+		UniqueCloners:  7,
+		TopReferrers:   []appv1.GitStarReferrer{{Referrer: "github.com", Views: 30}, {Referrer: "google.com", Views: 20}},
+	}})
+	if got := testutil.ToFloat64(metrics.GitStarTrafficViews.With(with(l, "count", "unique"))); got != 28 {
+		t.Errorf("unique visitors = %v, want 28", got)
+	}
+	if got := testutil.ToFloat64(metrics.GitStarTrafficClones.With(with(l, "count", "total"))); got != 21 {
+		t.Errorf("clones = %v, want 21", got)
+	}
+	if got := testutil.ToFloat64(metrics.GitStarTrafficReferrerViews.With(with(l, "referrer", "google.com"))); got != 20 {
+		t.Errorf("views referred by google.com = %v, want 20", got)
+	}
+
+	// google.com left the top
+	exportMetrics(key, &appv1.GitStarStatus{Traffic: &appv1.GitStarTrafficStatus{
+		TopReferrers: []appv1.GitStarReferrer{{Referrer: "github.com", Views: 31}},
+	}})
+	if metrics.GitStarTrafficReferrerViews.Delete(with(l, "referrer", "google.com")) {
+		t.Error("series of google.com is still exported")
+	}
+
+	exportMetrics(key, nil)
+	if metrics.GitStarTrafficViews.Delete(with(l, "count", "total")) || metrics.GitStarTrafficReferrerViews.Delete(with(l, "referrer", "github.com")) {
+		t.Error("series of the deleted GitStar are still exported")
+	}
+}
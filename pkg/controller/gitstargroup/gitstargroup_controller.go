@@ -0,0 +1,226 @@
+package gitstargroup
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	defaultTopN        = 5
+	defaultDeltaPeriod = 24 * time.Hour
+)
+
+var log = logf.Log.WithName("controller_gitstargroup")
+
+// Add creates a new GitStarGroup Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started. The options, like MaxConcurrentReconciles, are shared by all Controllers.
+func Add(mgr manager.Manager, options controller.Options) error {
+	return add(mgr, newReconciler(mgr), options)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileGitStarGroup{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler, options controller.Options) error {
+	// Create a new controller
+	options.Reconciler = r
+	c, err := controller.New("gitstargroup-controller", mgr, options)
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource GitStarGroup
+	err = c.Watch(&source.Kind{Type: &appv1.GitStarGroup{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to GitStars and requeue every group selecting them
+	err = c.Watch(&source.Kind{Type: &appv1.GitStar{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: groupsOfGitStar(mgr.GetClient()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// groupsOfGitStar maps a GitStar to the GitStarGroups in its namespace whose selector matches it
+func groupsOfGitStar(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		groups := &appv1.GitStarGroupList{}
+		if err := c.List(context.TODO(), groups, client.InNamespace(a.Meta.GetNamespace())); err != nil {
+			log.Error(err, "list GitStarGroups failed!")
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, group := range groups.Items {
+			selector, err := selectorOfGroup(&group)
+			if err != nil || !selector.Matches(labels.Set(a.Meta.GetLabels())) {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: group.Namespace,
+				Name:      group.Name,
+			}})
+		}
+		return requests
+	}
+}
+
+// blank assignment to verify that ReconcileGitStarGroup implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileGitStarGroup{}
+
+// ReconcileGitStarGroup reconciles a GitStarGroup object
+type ReconcileGitStarGroup struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile sums up the stars and forks of the GitStars selected by a GitStarGroup and records them in its status
+func (r *ReconcileGitStarGroup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling GitStarGroup")
+
+	instance := &appv1.GitStarGroup{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	selector, err := selectorOfGroup(instance)
+	if err != nil {
+		reqLogger.Error(err, "selector of GitStarGroup is invalid!")
+		instance.Status.FailedReason = err.Error()
+		return reconcile.Result{}, r.client.Status().Update(context.TODO(), instance)
+	}
+
+	members := &appv1.GitStarList{}
+	err = r.client.List(context.TODO(), members,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	deltaPeriod := defaultDeltaPeriod
+	if instance.Spec.DeltaPeriod != nil && instance.Spec.DeltaPeriod.Duration > 0 {
+		deltaPeriod = instance.Spec.DeltaPeriod.Duration
+	}
+
+	now := time.Now()
+	status := computeStatus(instance, members.Items, now, deltaPeriod)
+	if !statusEqual(&instance.Status, &status) {
+		status.UpdatedAt = metav1.NewTime(now)
+		instance.Status = status
+		if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	// requeue at the end of the period so the deltas roll over even without member changes
+	return reconcile.Result{RequeueAfter: instance.Status.PeriodStartedAt.Add(deltaPeriod).Sub(now)}, nil
+}
+
+// computeStatus computes the totals, deltas and top members of the group, it doesn't set UpdatedAt
+func computeStatus(instance *appv1.GitStarGroup, members []appv1.GitStar, now time.Time, deltaPeriod time.Duration) appv1.GitStarGroupStatus {
+	status := appv1.GitStarGroupStatus{
+		Members:          int32(len(members)),
+		PeriodStartedAt:  instance.Status.PeriodStartedAt,
+		PeriodStartStars: instance.Status.PeriodStartStars,
+		PeriodStartForks: instance.Status.PeriodStartForks,
+		UpdatedAt:        instance.Status.UpdatedAt,
+	}
+
+	all := make([]appv1.GitStarGroupMember, 0, len(members))
+	for _, member := range members {
+		if member.Status.FailedReason != "" {
+			status.FailedMembers++
+		}
+		status.TotalStars += member.Status.StarNumber
+		status.TotalForks += member.Status.ForkNumber
+		all = append(all, appv1.GitStarGroupMember{
+			Name:       member.Name,
+			RepoName:   member.Spec.RepoName,
+			StarNumber: member.Status.StarNumber,
+			ForkNumber: member.Status.ForkNumber,
+		})
+	}
+
+	if status.PeriodStartedAt.IsZero() || now.Sub(status.PeriodStartedAt.Time) >= deltaPeriod {
+		status.PeriodStartedAt = metav1.NewTime(now)
+		status.PeriodStartStars = status.TotalStars
+		status.PeriodStartForks = status.TotalForks
+	}
+	status.StarsDelta = status.TotalStars - status.PeriodStartStars
+	status.ForksDelta = status.TotalForks - status.PeriodStartForks
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].StarNumber != all[j].StarNumber {
+			return all[i].StarNumber > all[j].StarNumber
+		}
+		return all[i].Name < all[j].Name
+	})
+	topN := int(instance.Spec.TopN)
+	if topN <= 0 {
+		topN = defaultTopN
+	}
+	if len(all) > topN {
+		all = all[:topN]
+	}
+	if len(all) > 0 {
+		status.TopMembers = all
+	}
+
+	return status
+}
+
+// statusEqual compares two statuses ignoring UpdatedAt
+func statusEqual(a, b *appv1.GitStarGroupStatus) bool {
+	if a.Members != b.Members || a.FailedMembers != b.FailedMembers ||
+		a.TotalStars != b.TotalStars || a.TotalForks != b.TotalForks ||
+		a.StarsDelta != b.StarsDelta || a.ForksDelta != b.ForksDelta ||
+		!a.PeriodStartedAt.Equal(&b.PeriodStartedAt) ||
+		a.PeriodStartStars != b.PeriodStartStars || a.PeriodStartForks != b.PeriodStartForks ||
+		a.FailedReason != b.FailedReason || len(a.TopMembers) != len(b.TopMembers) {
+		return false
+	}
+	for i := range a.TopMembers {
+		if a.TopMembers[i] != b.TopMembers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func selectorOfGroup(group *appv1.GitStarGroup) (labels.Selector, error) {
+	if group.Spec.Selector == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(group.Spec.Selector)
+}
@@ -0,0 +1,144 @@
+package gitstargroup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"gitstar-operator/pkg/apis"
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+func newTestGitStar(name string, stars, forks int64, failedReason string) appv1.GitStar {
+	return appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       appv1.GitStarSpec{RepoName: "kuri-su/" + name},
+		Status:     appv1.GitStarStatus{StarNumber: stars, ForkNumber: forks, FailedReason: failedReason},
+	}
+}
+
+func TestComputeStatus(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) metav1.Time { return metav1.NewTime(now.Add(-d)) }
+	member := func(name string, stars int64) appv1.GitStarGroupMember {
+		return appv1.GitStarGroupMember{Name: name, RepoName: "kuri-su/" + name, StarNumber: stars}
+	}
+	tests := []struct {
+		name    string
+		group   appv1.GitStarGroup
+		members []appv1.GitStar
+		want    appv1.GitStarGroupStatus
+	}{
+		{
+			name:  "empty group",
+			group: appv1.GitStarGroup{},
+			want:  appv1.GitStarGroupStatus{PeriodStartedAt: metav1.NewTime(now)},
+		},
+		{
+			name:  "members without history",
+			group: appv1.GitStarGroup{},
+			members: []appv1.GitStar{
+				newTestGitStar("kblog-ui", 0, 0, ""),
+				newTestGitStar("kblog", 0, 0, ""),
+			},
+			want: appv1.GitStarGroupStatus{
+				Members:         2,
+				PeriodStartedAt: metav1.NewTime(now),
+				TopMembers:      []appv1.GitStarGroupMember{member("kblog", 0), member("kblog-ui", 0)},
+			},
+		},
+		{
+			name: "deltas of the current period",
+			group: appv1.GitStarGroup{Status: appv1.GitStarGroupStatus{
+				PeriodStartedAt: ago(time.Hour), PeriodStartStars: 40, PeriodStartForks: 5,
+			}},
+			members: []appv1.GitStar{
+				newTestGitStar("kblog", 42, 7, ""),
+				newTestGitStar("kblog-ui", 3, 1, "502 Bad Gateway"),
+			},
+			want: appv1.GitStarGroupStatus{
+				Members: 2, FailedMembers: 1, TotalStars: 45, TotalForks: 8, StarsDelta: 5, ForksDelta: 3,
+				PeriodStartedAt: ago(time.Hour), PeriodStartStars: 40, PeriodStartForks: 5,
+				TopMembers: []appv1.GitStarGroupMember{
+					{Name: "kblog", RepoName: "kuri-su/kblog", StarNumber: 42, ForkNumber: 7},
+					{Name: "kblog-ui", RepoName: "kuri-su/kblog-ui", StarNumber: 3, ForkNumber: 1},
+				},
+			},
+		},
+		{
+			name: "period over",
+			group: appv1.GitStarGroup{Status: appv1.GitStarGroupStatus{
+				PeriodStartedAt: ago(25 * time.Hour), PeriodStartStars: 40,
+			}},
+			members: []appv1.GitStar{newTestGitStar("kblog", 42, 0, "")},
+			want: appv1.GitStarGroupStatus{
+				Members: 1, TotalStars: 42, PeriodStartedAt: metav1.NewTime(now), PeriodStartStars: 42,
+				TopMembers: []appv1.GitStarGroupMember{member("kblog", 42)},
+			},
+		},
+		{
+			name:  "ties in topN",
+			group: appv1.GitStarGroup{Spec: appv1.GitStarGroupSpec{TopN: 2}},
+			members: []appv1.GitStar{
+				newTestGitStar("c", 10, 0, ""),
+				newTestGitStar("b", 10, 0, ""),
+				newTestGitStar("d", 20, 0, ""),
+				newTestGitStar("a", 10, 0, ""),
+			},
+			want: appv1.GitStarGroupStatus{
+				Members: 4, TotalStars: 50, PeriodStartedAt: metav1.NewTime(now), PeriodStartStars: 50,
+				TopMembers: []appv1.GitStarGroupMember{member("d", 20), member("a", 10)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := computeStatus(&tt.group, tt.members, now, defaultDeltaPeriod)
+		if !statusEqual(&got, &tt.want) {
+			t.Errorf("%s: status = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGroupsOfGitStar(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	group := func(namespace, name string, selector *metav1.LabelSelector) *appv1.GitStarGroup {
+		return &appv1.GitStarGroup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       appv1.GitStarGroupSpec{Selector: selector},
+		}
+	}
+	c := fake.NewFakeClientWithScheme(s,
+		group("default", "all", nil),
+		group("default", "blog", &metav1.LabelSelector{MatchLabels: map[string]string{"team": "blog"}}),
+		group("default", "infra", &metav1.LabelSelector{MatchLabels: map[string]string{"team": "infra"}}),
+		group("default", "invalid", &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "team", Operator: "Unknown"},
+		}}),
+		group("other", "all", nil),
+	)
+
+	gitStar := newTestGitStar("kblog", 42, 0, "")
+	gitStar.Labels = map[string]string{"team": "blog"}
+	requests := groupsOfGitStar(c)(handler.MapObject{Meta: &gitStar, Object: &gitStar})
+	want := []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "default", Name: "all"}},
+		{NamespacedName: types.NamespacedName{Namespace: "default", Name: "blog"}},
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,307 @@
+package gitstarleaderboard
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/metrics"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	defaultGrowthWindow = 7 * 24 * time.Hour
+	defaultPeriod       = 24 * time.Hour
+	// maxStarSamples caps the samples kept per member in the status, the samples in the growth window are at least a
+	// 47th of it apart so one more fits, the baseline taken before the window start
+	maxStarSamples = 48
+)
+
+var log = logf.Log.WithName("controller_gitstarleaderboard")
+
+// Add creates a new GitStarLeaderboard Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started. The options, like MaxConcurrentReconciles, are shared by all Controllers.
+func Add(mgr manager.Manager, options controller.Options) error {
+	return add(mgr, newReconciler(mgr), options)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileGitStarLeaderboard{
+		client:        mgr.GetClient(),
+		scheme:        mgr.GetScheme(),
+		metricsLabels: map[types.NamespacedName][]prometheus.Labels{},
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler, options controller.Options) error {
+	// Create a new controller
+	options.Reconciler = r
+	c, err := controller.New("gitstarleaderboard-controller", mgr, options)
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource GitStarLeaderboard
+	err = c.Watch(&source.Kind{Type: &appv1.GitStarLeaderboard{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to GitStars and requeue every leaderboard selecting them
+	err = c.Watch(&source.Kind{Type: &appv1.GitStar{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: leaderboardsOfGitStar(mgr.GetClient()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// leaderboardsOfGitStar maps a GitStar to the GitStarLeaderboards in its namespace whose selector matches it
+func leaderboardsOfGitStar(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		leaderboards := &appv1.GitStarLeaderboardList{}
+		if err := c.List(context.TODO(), leaderboards, client.InNamespace(a.Meta.GetNamespace())); err != nil {
+			log.Error(err, "list GitStarLeaderboards failed!")
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, leaderboard := range leaderboards.Items {
+			selector, err := selectorOfLeaderboard(&leaderboard)
+			if err != nil || !selector.Matches(labels.Set(a.Meta.GetLabels())) {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: leaderboard.Namespace,
+				Name:      leaderboard.Name,
+			}})
+		}
+		return requests
+	}
+}
+
+// blank assignment to verify that ReconcileGitStarLeaderboard implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileGitStarLeaderboard{}
+
+// ReconcileGitStarLeaderboard reconciles a GitStarLeaderboard object
+type ReconcileGitStarLeaderboard struct {
+	client client.Client
+	scheme *runtime.Scheme
+
+	// metricsLabels remembers the exported rank series of every leaderboard so stale ones can be deleted
+	mu            sync.Mutex
+	metricsLabels map[types.NamespacedName][]prometheus.Labels
+}
+
+// Reconcile ranks the GitStars selected by a GitStarLeaderboard and records the ranking in its status
+func (r *ReconcileGitStarLeaderboard) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling GitStarLeaderboard")
+
+	instance := &appv1.GitStarLeaderboard{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			r.exportRanks(request.NamespacedName, nil)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	selector, err := selectorOfLeaderboard(instance)
+	if err == nil && instance.Spec.OrderBy != "" &&
+		instance.Spec.OrderBy != appv1.LeaderboardOrderByStars && instance.Spec.OrderBy != appv1.LeaderboardOrderByGrowth {
+		err = fmt.Errorf("orderBy '%s' is invalid, must be '%s' or '%s'",
+			instance.Spec.OrderBy, appv1.LeaderboardOrderByStars, appv1.LeaderboardOrderByGrowth)
+	}
+	if err != nil {
+		reqLogger.Error(err, "spec of GitStarLeaderboard is invalid!")
+		instance.Status.FailedReason = err.Error()
+		return reconcile.Result{}, r.client.Status().Update(context.TODO(), instance)
+	}
+
+	members := &appv1.GitStarList{}
+	err = r.client.List(context.TODO(), members,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	period := defaultPeriod
+	if instance.Spec.Period != nil && instance.Spec.Period.Duration > 0 {
+		period = instance.Spec.Period.Duration
+	}
+
+	now := time.Now()
+	status := computeStatus(instance, members.Items, now, period)
+	if !reflect.DeepEqual(instance.Status.Entries, status.Entries) ||
+		!reflect.DeepEqual(instance.Status.History, status.History) ||
+		!reflect.DeepEqual(instance.Status.PeriodStartRanks, status.PeriodStartRanks) ||
+		!instance.Status.PeriodStartedAt.Equal(&status.PeriodStartedAt) ||
+		instance.Status.FailedReason != "" {
+		status.UpdatedAt = metav1.NewTime(now)
+		instance.Status = status
+		if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	r.exportRanks(request.NamespacedName, instance.Status.Entries)
+
+	// requeue at the end of the period so the rank changes roll over even without member changes
+	return reconcile.Result{RequeueAfter: instance.Status.PeriodStartedAt.Add(period).Sub(now)}, nil
+}
+
+// exportRanks sets the gitstar_rank series of a leaderboard and deletes the series of entries which are gone
+func (r *ReconcileGitStarLeaderboard) exportRanks(leaderboard types.NamespacedName, entries []appv1.GitStarLeaderboardEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range r.metricsLabels[leaderboard] {
+		metrics.GitStarRank.Delete(l)
+	}
+	delete(r.metricsLabels, leaderboard)
+
+	for _, entry := range entries {
+		l := prometheus.Labels{
+			"namespace":   leaderboard.Namespace,
+			"leaderboard": leaderboard.Name,
+			"gitstar":     entry.Name,
+			"repo":        entry.RepoName,
+		}
+		metrics.GitStarRank.With(l).Set(float64(entry.Rank))
+		r.metricsLabels[leaderboard] = append(r.metricsLabels[leaderboard], l)
+	}
+}
+
+// computeStatus records a new sample for every member, ranks the members and computes the rank changes,
+// it doesn't set UpdatedAt
+func computeStatus(instance *appv1.GitStarLeaderboard, members []appv1.GitStar, now time.Time, period time.Duration) appv1.GitStarLeaderboardStatus {
+	growthWindow := defaultGrowthWindow
+	if instance.Spec.GrowthWindow != nil && instance.Spec.GrowthWindow.Duration > 0 {
+		growthWindow = instance.Spec.GrowthWindow.Duration
+	}
+
+	history := map[string][]appv1.StarSample{}
+	for _, h := range instance.Status.History {
+		history[h.Name] = h.Samples
+	}
+
+	status := appv1.GitStarLeaderboardStatus{
+		PeriodStartedAt:  instance.Status.PeriodStartedAt,
+		PeriodStartRanks: instance.Status.PeriodStartRanks,
+		UpdatedAt:        instance.Status.UpdatedAt,
+	}
+
+	entries := make([]appv1.GitStarLeaderboardEntry, 0, len(members))
+	for _, member := range members {
+		samples := addSample(history[member.Name], member.Status, now.Add(-growthWindow), growthWindow/(maxStarSamples-1))
+		if len(samples) > 0 {
+			status.History = append(status.History, appv1.GitStarLeaderboardHistory{Name: member.Name, Samples: samples})
+		}
+
+		entry := appv1.GitStarLeaderboardEntry{
+			Name:       member.Name,
+			RepoName:   member.Spec.RepoName,
+			StarNumber: member.Status.StarNumber,
+		}
+		if len(samples) > 0 {
+			entry.Growth = member.Status.StarNumber - samples[0].StarNumber
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(status.History, func(i, j int) bool { return status.History[i].Name < status.History[j].Name })
+
+	value := func(entry *appv1.GitStarLeaderboardEntry) int64 {
+		if instance.Spec.OrderBy == appv1.LeaderboardOrderByGrowth {
+			return entry.Growth
+		}
+		return entry.StarNumber
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if value(&entries[i]) != value(&entries[j]) {
+			return value(&entries[i]) > value(&entries[j])
+		}
+		return entries[i].Name < entries[j].Name
+	})
+
+	if status.PeriodStartedAt.IsZero() || now.Sub(status.PeriodStartedAt.Time) >= period {
+		status.PeriodStartedAt = metav1.NewTime(now)
+		status.PeriodStartRanks = map[string]int32{}
+		for i := range entries {
+			status.PeriodStartRanks[entries[i].Name] = int32(i + 1)
+		}
+	}
+
+	for i := range entries {
+		entries[i].Rank = int32(i + 1)
+		if previous, ok := status.PeriodStartRanks[entries[i].Name]; ok {
+			entries[i].RankChange = previous - entries[i].Rank
+		}
+		if i > 0 {
+			entries[i].GapToNext = value(&entries[i-1]) - value(&entries[i])
+		}
+	}
+	if len(entries) > 0 {
+		status.Entries = entries
+	}
+
+	return status
+}
+
+// addSample appends the current star number of a GitStar to its samples if it was fetched at least spacing after the
+// last sample, and drops the samples which are not needed to compute the growth since windowStart anymore. At most
+// maxStarSamples are kept, the oldest are dropped when a shorter spacing left more.
+func addSample(samples []appv1.StarSample, gitStarStatus appv1.GitStarStatus, windowStart time.Time, spacing time.Duration) []appv1.StarSample {
+	add := gitStarStatus.FailedReason == "" && !gitStarStatus.UpdatedAt.IsZero()
+	if add && len(samples) > 0 {
+		elapsed := gitStarStatus.UpdatedAt.Sub(samples[len(samples)-1].Time.Time)
+		add = elapsed > 0 && elapsed >= spacing
+	}
+	if add {
+		samples = append(samples, appv1.StarSample{
+			Time:       gitStarStatus.UpdatedAt,
+			StarNumber: gitStarStatus.StarNumber,
+		})
+	}
+
+	// keep the last sample taken before the window start as the baseline of the growth
+	first := 0
+	for first+1 < len(samples) && !samples[first+1].Time.After(windowStart) {
+		first++
+	}
+	if len(samples)-first > maxStarSamples {
+		first = len(samples) - maxStarSamples
+	}
+	return samples[first:]
+}
+
+func selectorOfLeaderboard(leaderboard *appv1.GitStarLeaderboard) (labels.Selector, error) {
+	if leaderboard.Spec.Selector == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(leaderboard.Spec.Selector)
+}
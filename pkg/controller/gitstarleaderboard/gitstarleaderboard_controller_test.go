@@ -0,0 +1,162 @@
+package gitstarleaderboard
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+var testNow = time.Date(2020, 5, 8, 12, 0, 0, 0, time.UTC)
+
+func ago(d time.Duration) metav1.Time {
+	return metav1.NewTime(testNow.Add(-d))
+}
+
+func newTestGitStar(name string, stars int64, updatedAt metav1.Time) appv1.GitStar {
+	return appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       appv1.GitStarSpec{RepoName: "kuri-su/" + name},
+		Status:     appv1.GitStarStatus{StarNumber: stars, UpdatedAt: updatedAt},
+	}
+}
+
+// ranking returns the names of the entries with their rank, rank change, growth and gap to the next entry
+func ranking(status appv1.GitStarLeaderboardStatus) [][5]interface{} {
+	var got [][5]interface{}
+	for _, e := range status.Entries {
+		got = append(got, [5]interface{}{e.Name, e.Rank, e.RankChange, e.Growth, e.GapToNext})
+	}
+	return got
+}
+
+func TestComputeStatus(t *testing.T) {
+	history := []appv1.GitStarLeaderboardHistory{
+		{Name: "kblog", Samples: []appv1.StarSample{{Time: ago(8 * 24 * time.Hour), StarNumber: 30}}},
+		{Name: "kblog-ui", Samples: []appv1.StarSample{{Time: ago(8 * 24 * time.Hour), StarNumber: 1}}},
+	}
+	members := []appv1.GitStar{
+		newTestGitStar("kblog", 42, ago(time.Minute)),
+		newTestGitStar("kblog-ui", 21, ago(time.Minute)),
+		newTestGitStar("kblog-docs", 42, ago(time.Minute)),
+		newTestGitStar("new", 0, metav1.Time{}),
+	}
+	tests := []struct {
+		name   string
+		spec   appv1.GitStarLeaderboardSpec
+		status appv1.GitStarLeaderboardStatus
+		want   [][5]interface{}
+	}{
+		{
+			name:   "stars, ties by name",
+			status: appv1.GitStarLeaderboardStatus{History: history},
+			want: [][5]interface{}{
+				{"kblog", int32(1), int32(0), int64(12), int64(0)},
+				{"kblog-docs", int32(2), int32(0), int64(0), int64(0)},
+				{"kblog-ui", int32(3), int32(0), int64(20), int64(21)},
+				{"new", int32(4), int32(0), int64(0), int64(21)},
+			},
+		},
+		{
+			name:   "growth",
+			spec:   appv1.GitStarLeaderboardSpec{OrderBy: appv1.LeaderboardOrderByGrowth},
+			status: appv1.GitStarLeaderboardStatus{History: history},
+			want: [][5]interface{}{
+				{"kblog-ui", int32(1), int32(0), int64(20), int64(0)},
+				{"kblog", int32(2), int32(0), int64(12), int64(8)},
+				{"kblog-docs", int32(3), int32(0), int64(0), int64(12)},
+				{"new", int32(4), int32(0), int64(0), int64(0)},
+			},
+		},
+		{
+			name: "rank changes of the current period",
+			status: appv1.GitStarLeaderboardStatus{
+				History:          history,
+				PeriodStartedAt:  ago(time.Hour),
+				PeriodStartRanks: map[string]int32{"kblog": 2, "kblog-ui": 1, "kblog-docs": 3},
+			},
+			want: [][5]interface{}{
+				{"kblog", int32(1), int32(1), int64(12), int64(0)},
+				{"kblog-docs", int32(2), int32(1), int64(0), int64(0)},
+				{"kblog-ui", int32(3), int32(-2), int64(20), int64(21)},
+				{"new", int32(4), int32(0), int64(0), int64(21)},
+			},
+		},
+		{
+			name: "period over",
+			status: appv1.GitStarLeaderboardStatus{
+				History:          history,
+				PeriodStartedAt:  ago(25 * time.Hour),
+				PeriodStartRanks: map[string]int32{"kblog": 2, "kblog-ui": 1},
+			},
+			want: [][5]interface{}{
+				{"kblog", int32(1), int32(0), int64(12), int64(0)},
+				{"kblog-docs", int32(2), int32(0), int64(0), int64(0)},
+				{"kblog-ui", int32(3), int32(0), int64(20), int64(21)},
+				{"new", int32(4), int32(0), int64(0), int64(21)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := computeStatus(&appv1.GitStarLeaderboard{Spec: tt.spec, Status: tt.status}, members, testNow, defaultPeriod)
+		if !reflect.DeepEqual(ranking(got), tt.want) {
+			t.Errorf("%s: ranking = %v, want %v", tt.name, ranking(got), tt.want)
+		}
+	}
+
+	// an empty leaderboard has no entries
+	got := computeStatus(&appv1.GitStarLeaderboard{}, nil, testNow, defaultPeriod)
+	if got.Entries != nil || got.History != nil || !got.PeriodStartedAt.Equal(&metav1.Time{Time: testNow}) {
+		t.Errorf("status = %+v, want no entries and a new period", got)
+	}
+}
+
+func TestAddSample(t *testing.T) {
+	window := 48 * time.Hour
+	spacing := window / (maxStarSamples - 1)
+	status := func(stars int64, updatedAt metav1.Time) appv1.GitStarStatus {
+		return appv1.GitStarStatus{StarNumber: stars, UpdatedAt: updatedAt}
+	}
+
+	samples := addSample(nil, status(1, ago(time.Hour)), testNow.Add(-window), spacing)
+	if len(samples) != 1 {
+		t.Fatalf("samples = %v, want the first sample", samples)
+	}
+	// a fetch closer than spacing to the last sample, a failed and a repeated one add no sample
+	for _, s := range []appv1.GitStarStatus{
+		status(2, ago(time.Hour-spacing/2)),
+		{StarNumber: 3, UpdatedAt: ago(time.Minute), FailedReason: "502 Bad Gateway"},
+		status(1, ago(time.Hour)),
+	} {
+		if samples = addSample(samples, s, testNow.Add(-window), spacing); len(samples) != 1 {
+			t.Fatalf("samples = %v after %+v, want one sample", samples, s)
+		}
+	}
+	if samples = addSample(samples, status(4, ago(time.Hour-spacing)), testNow.Add(-window), spacing); len(samples) != 2 {
+		t.Fatalf("samples = %v, want a second sample", samples)
+	}
+
+	// fetched every 10 minutes for a week, the last sample before the window start is kept as the baseline
+	samples = nil
+	for at := testNow.Add(-7 * 24 * time.Hour); !at.After(testNow); at = at.Add(10 * time.Minute) {
+		samples = addSample(samples, status(at.Unix(), metav1.NewTime(at)), at.Add(-window), spacing)
+		if len(samples) > maxStarSamples {
+			t.Fatalf("%d samples at %v, want at most %d", len(samples), at, maxStarSamples)
+		}
+	}
+	if first := samples[0].Time.Time; first.After(testNow.Add(-window)) || first.Before(testNow.Add(-window-spacing)) {
+		t.Errorf("first sample at %v, want at most %v before the window start %v", first, spacing, testNow.Add(-window))
+	}
+
+	// a shorter spacing leaves more samples, the oldest are dropped
+	samples = nil
+	for i := 0; i < 2*maxStarSamples; i++ {
+		samples = addSample(samples, status(int64(i), ago(time.Duration(2*maxStarSamples-i)*time.Minute)), testNow.Add(-window), 0)
+	}
+	if len(samples) != maxStarSamples || samples[len(samples)-1].StarNumber != 2*maxStarSamples-1 {
+		t.Errorf("%d samples ending with %+v, want the %d newest", len(samples), samples[len(samples)-1], maxStarSamples)
+	}
+}
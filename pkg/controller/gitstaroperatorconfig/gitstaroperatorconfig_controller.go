@@ -0,0 +1,95 @@
+package gitstaroperatorconfig
+
+import (
+	"context"
+	"fmt"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/operatorconfig"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_gitstaroperatorconfig")
+
+// Add creates a new GitStarOperatorConfig Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started. The options, like MaxConcurrentReconciles, are shared by all Controllers.
+func Add(mgr manager.Manager, options controller.Options) error {
+	return add(mgr, newReconciler(mgr), options)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileGitStarOperatorConfig{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler, options controller.Options) error {
+	// Create a new controller
+	options.Reconciler = r
+	c, err := controller.New("gitstaroperatorconfig-controller", mgr, options)
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource GitStarOperatorConfig
+	err = c.Watch(&source.Kind{Type: &appv1.GitStarOperatorConfig{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// blank assignment to verify that ReconcileGitStarOperatorConfig implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileGitStarOperatorConfig{}
+
+// ReconcileGitStarOperatorConfig reconciles a GitStarOperatorConfig object
+type ReconcileGitStarOperatorConfig struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile validates a GitStarOperatorConfig and reports in its status whether the operator loaded it, the
+// settings themselves are read by the other controllers on each reconcile
+func (r *ReconcileGitStarOperatorConfig) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+	reqLogger.Info("Reconciling GitStarOperatorConfig")
+
+	instance := &appv1.GitStarOperatorConfig{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("GitStarOperatorConfig was deleted, the defaults are used")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	status := appv1.GitStarOperatorConfigStatus{ObservedGeneration: instance.Generation}
+	if instance.Name != operatorconfig.Name {
+		status.FailedReason = fmt.Sprintf("ignored, the operator reads the GitStarOperatorConfig named '%s'", operatorconfig.Name)
+	} else if err := operatorconfig.Validate(&instance.Spec); err != nil {
+		status.FailedReason = err.Error()
+	} else {
+		reqLogger.Info("GitStarOperatorConfig loaded", "Generation", instance.Generation)
+	}
+
+	if instance.Status == status {
+		return reconcile.Result{}, nil
+	}
+	instance.Status = status
+	if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+		reqLogger.Error(err, "update status of GitStarOperatorConfig failed!")
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
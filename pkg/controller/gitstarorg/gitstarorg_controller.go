@@ -0,0 +1,359 @@
+package gitstarorg
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/gitOperation"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// LabelGitStarOrg is set on every GitStar created by a GitStarOrg, its value is the GitStarOrg name
+	LabelGitStarOrg = "app.kuricat.com/gitstar-org"
+
+	defaultSyncPeriod = time.Hour
+	// minFailureBackoff is the wait after a failed sync, it doubles with every failure in a row up to the sync period
+	minFailureBackoff = time.Minute
+	// listReposTimeout bounds the listing of the repos of an owner
+	listReposTimeout = 2 * time.Minute
+	// CronJob names are limited to 52 characters and the GitStar CronJob gets a "-gitstar" suffix
+	maxGitStarNameLength = 44
+)
+
+var log = logf.Log.WithName("controller_gitstarorg")
+
+// Add creates a new GitStarOrg Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started. The options, like MaxConcurrentReconciles, are shared by all Controllers.
+func Add(mgr manager.Manager, options controller.Options) error {
+	return add(mgr, newReconciler(mgr), options)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileGitStarOrg{
+		client:    mgr.GetClient(),
+		reader:    mgr.GetAPIReader(),
+		scheme:    mgr.GetScheme(),
+		listRepos: gitOperation.ListReposOfOwner,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler, options controller.Options) error {
+	// Create a new controller
+	options.Reconciler = r
+	c, err := controller.New("gitstarorg-controller", mgr, options)
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource GitStarOrg
+	err = c.Watch(&source.Kind{Type: &appv1.GitStarOrg{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource GitStar and requeue the owner GitStarOrg
+	err = c.Watch(&source.Kind{Type: &appv1.GitStar{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &appv1.GitStarOrg{},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// blank assignment to verify that ReconcileGitStarOrg implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileGitStarOrg{}
+
+// ReconcileGitStarOrg reconciles a GitStarOrg object
+type ReconcileGitStarOrg struct {
+	client client.Client
+	// reader reads the token ConfigMap from the apiserver, it may be outside the namespaces watched by the cache
+	reader client.Reader
+	scheme *runtime.Scheme
+	// listRepos lists the repos of an owner from GitHub
+	listRepos func(ctx context.Context, owner, token string) ([]*github.Repository, error)
+}
+
+// Reconcile lists the repositories of the GitStarOrg owner and creates or deletes the owned GitStar objects
+// so that exactly the matching repositories are tracked
+func (r *ReconcileGitStarOrg) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling GitStarOrg")
+
+	instance := &appv1.GitStarOrg{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// owned GitStars are removed by the garbage collector
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	syncPeriod := defaultSyncPeriod
+	if instance.Spec.SyncPeriod != nil && instance.Spec.SyncPeriod.Duration > 0 {
+		syncPeriod = instance.Spec.SyncPeriod.Duration
+	}
+
+	// don't hit the GitHub API on every GitStar status update, only once per sync period or failure backoff
+	if wait := nextSync(&instance.Status, instance.Generation, syncPeriod, time.Now()); wait > 0 {
+		return reconcile.Result{RequeueAfter: wait}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), listReposTimeout)
+	defer cancel()
+	repos, err := r.listRepos(ctx, instance.Spec.Owner, gitOperation.GetGitHubOAuthTokenForNamespace(r.reader, instance.Namespace))
+	if err != nil {
+		reqLogger.Error(err, "list repos of owner failed!")
+		return r.failSync(instance, syncPeriod, err)
+	}
+
+	matched, err := filterRepos(instance, repos)
+	if err != nil {
+		reqLogger.Error(err, "filter repos failed!")
+		return r.failSync(instance, syncPeriod, err)
+	}
+
+	tracked, conflicts, err := r.syncGitStars(instance, matched)
+	if err != nil {
+		reqLogger.Error(err, "sync GitStars of GitStarOrg failed!")
+		return reconcile.Result{}, err
+	}
+
+	var totalStars int64
+	for _, repo := range matched {
+		totalStars += int64(repo.GetStargazersCount())
+	}
+
+	err = r.updateStatus(instance, func(status *appv1.GitStarOrgStatus) {
+		status.TotalRepos = int32(len(repos))
+		status.MatchedRepos = int32(len(matched))
+		status.TrackedRepos = int32(tracked)
+		status.TotalStars = totalStars
+		status.ConflictingGitStars = conflicts
+		status.ObservedGeneration = instance.Generation
+		status.UpdatedAt = metav1.NewTime(time.Now())
+		status.FailedReason = ""
+		status.FailedAt = metav1.Time{}
+		status.Failures = 0
+	})
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	reqLogger.Info(fmt.Sprintf("sync owner '%s' success, matched %d of %d repos", instance.Spec.Owner, len(matched), len(repos)))
+	return reconcile.Result{RequeueAfter: syncPeriod}, nil
+}
+
+// syncGitStars creates a GitStar for every matched repo and deletes owned GitStars of repos no longer matched,
+// it returns the number of GitStars owned by the GitStarOrg afterwards and the names of the GitStars it doesn't own
+// that block a matched repo. Owned GitStars are matched by repo rather than by name, so a GitStar keeps its name when
+// GenerateGitStarName changes.
+func (r *ReconcileGitStarOrg) syncGitStars(instance *appv1.GitStarOrg, matched []*github.Repository) (int, []string, error) {
+	// keyed by the lowercase repo name, GitHub names are case insensitive
+	desired := map[string]string{}
+	for _, repo := range matched {
+		desired[strings.ToLower(repo.GetFullName())] = repo.GetFullName()
+	}
+
+	existing := &appv1.GitStarList{}
+	err := r.client.List(context.TODO(), existing,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{LabelGitStarOrg: instance.Name})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tracked := 0
+	var conflicts []string
+	for i := range existing.Items {
+		gitStar := &existing.Items[i]
+		if !metav1.IsControlledBy(gitStar, instance) {
+			continue
+		}
+		if key := strings.ToLower(gitStar.Spec.RepoName); desired[key] != "" {
+			delete(desired, key)
+			tracked++
+			continue
+		}
+		log.Info("Deleting a GitStar no longer matched", "GitStar.Namespace", gitStar.Namespace, "GitStar.Name", gitStar.Name)
+		if err := r.client.Delete(context.TODO(), gitStar); err != nil && !errors.IsNotFound(err) {
+			return 0, nil, err
+		}
+	}
+
+	for _, repoName := range desired {
+		gitStar := &appv1.GitStar{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      GenerateGitStarName(repoName),
+				Namespace: instance.Namespace,
+				Labels: map[string]string{
+					LabelGitStarOrg: instance.Name,
+				},
+			},
+			Spec: appv1.GitStarSpec{
+				RepoName: repoName,
+			},
+		}
+		if err := controllerutil.SetControllerReference(instance, gitStar, r.scheme); err != nil {
+			return 0, nil, err
+		}
+
+		// a GitStar with the same name created by someone else is left alone and reported in the status
+		found := &appv1.GitStar{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: gitStar.Namespace, Name: gitStar.Name}, found)
+		if err == nil {
+			log.Info("Skip GitStar not owned by GitStarOrg", "GitStar.Namespace", found.Namespace, "GitStar.Name", found.Name,
+				"Repo", repoName)
+			conflicts = append(conflicts, found.Name)
+			continue
+		} else if !errors.IsNotFound(err) {
+			return 0, nil, err
+		}
+
+		log.Info("Creating a new GitStar", "GitStar.Namespace", gitStar.Namespace, "GitStar.Name", gitStar.Name)
+		if err := r.client.Create(context.TODO(), gitStar); err != nil {
+			return 0, nil, err
+		}
+		tracked++
+	}
+
+	sort.Strings(conflicts)
+	return tracked, conflicts, nil
+}
+
+// failSync records the failure of a sync and requeues the GitStarOrg after the failure backoff
+func (r *ReconcileGitStarOrg) failSync(instance *appv1.GitStarOrg, syncPeriod time.Duration, err error) (reconcile.Result, error) {
+	err = r.updateStatus(instance, func(status *appv1.GitStarOrgStatus) {
+		status.ObservedGeneration = instance.Generation
+		status.FailedReason = err.Error()
+		status.FailedAt = metav1.NewTime(time.Now())
+		status.Failures++
+	})
+	return reconcile.Result{RequeueAfter: failureBackoff(instance.Status.Failures, syncPeriod)}, err
+}
+
+// nextSync returns the wait before the repos of a GitStarOrg are listed again, 0 when they are due: after a spec
+// change, a sync period after the last sync or a failure backoff after the last failure
+func nextSync(status *appv1.GitStarOrgStatus, generation int64, syncPeriod time.Duration, now time.Time) time.Duration {
+	if status.ObservedGeneration != generation {
+		return 0
+	}
+	next := status.UpdatedAt.Add(syncPeriod)
+	if status.FailedReason != "" {
+		next = status.FailedAt.Add(failureBackoff(status.Failures, syncPeriod))
+	}
+	if wait := next.Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// failureBackoff returns the wait after failures syncs failed in a row, it doubles from minFailureBackoff up to the
+// sync period
+func failureBackoff(failures int32, syncPeriod time.Duration) time.Duration {
+	backoff := minFailureBackoff
+	for i := int32(1); i < failures && backoff < syncPeriod; i++ {
+		backoff *= 2
+	}
+	if backoff > syncPeriod {
+		return syncPeriod
+	}
+	return backoff
+}
+
+func (r *ReconcileGitStarOrg) updateStatus(instance *appv1.GitStarOrg, mutate func(status *appv1.GitStarOrgStatus)) error {
+	mutate(&instance.Status)
+	return r.client.Status().Update(context.TODO(), instance)
+}
+
+// filterRepos returns the repos which pass the include/exclude, stars, fork and archived filters of the GitStarOrg
+func filterRepos(instance *appv1.GitStarOrg, repos []*github.Repository) ([]*github.Repository, error) {
+	var include, exclude *regexp.Regexp
+	var err error
+	if instance.Spec.Include != "" {
+		if include, err = regexp.Compile(instance.Spec.Include); err != nil {
+			return nil, fmt.Errorf("include regex is invalid: %v", err)
+		}
+	}
+	if instance.Spec.Exclude != "" {
+		if exclude, err = regexp.Compile(instance.Spec.Exclude); err != nil {
+			return nil, fmt.Errorf("exclude regex is invalid: %v", err)
+		}
+	}
+
+	var matched []*github.Repository
+	for _, repo := range repos {
+		switch {
+		case repo.GetFork() && !instance.Spec.IncludeForks:
+		case repo.GetArchived() && !instance.Spec.IncludeArchived:
+		case int64(repo.GetStargazersCount()) < instance.Spec.MinStars:
+		case include != nil && !include.MatchString(repo.GetName()):
+		case exclude != nil && exclude.MatchString(repo.GetName()):
+		default:
+			matched = append(matched, repo)
+		}
+	}
+	return matched, nil
+}
+
+// GenerateGitStarName turns a full repo name like "kuri-su/kblog" into a valid object name like "kuri-su-kblog". A
+// name changed beyond that, like "kuri-su/kblog.io", or truncated gets a short hash of the full name as a suffix, so
+// that distinct repos of an owner never share a GitStar name.
+func GenerateGitStarName(fullName string) string {
+	separator := strings.Index(fullName, "/")
+	changed := false
+	var b strings.Builder
+	for i, r := range fullName {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			b.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			// GitHub names are case insensitive
+			b.WriteRune(r - 'A' + 'a')
+		case i == separator:
+			// all repos of an org share the owner, its separator alone can't make their names collide
+			b.WriteRune('-')
+		default:
+			b.WriteRune('-')
+			changed = true
+		}
+	}
+	name := b.String()
+	if !changed && len(name) <= maxGitStarNameLength && strings.Trim(name, "-") == name {
+		return name
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(strings.ToLower(fullName)))
+	suffix := fmt.Sprintf("-%08x", hash.Sum32())
+	if len(name) > maxGitStarNameLength-len(suffix) {
+		name = name[:maxGitStarNameLength-len(suffix)]
+	}
+	return strings.Trim(name, "-") + suffix
+}
@@ -0,0 +1,297 @@
+package gitstarorg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"gitstar-operator/pkg/apis"
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+const testToken = "0123456789012345678901234567890123456789"
+
+func TestGenerateGitStarName(t *testing.T) {
+	long := "kuri-su/" + strings.Repeat("a", 40)
+	tests := []struct {
+		fullName string
+		want     string
+	}{
+		{"kuri-su/kblog", "kuri-su-kblog"},
+		{"Kuri-Su/KBlog", "kuri-su-kblog"},
+		{"kuri-su/kblog.io", "kuri-su-kblog-io-" + hashOf("kuri-su/kblog.io")},
+		{"kuri-su/kblog_io", "kuri-su-kblog-io-" + hashOf("kuri-su/kblog_io")},
+		{"kuri-su/.github", "kuri-su--github-" + hashOf("kuri-su/.github")},
+		{"kuri-su/kblog-", "kuri-su-kblog-" + hashOf("kuri-su/kblog-")},
+		{long, "kuri-su-" + strings.Repeat("a", 27) + "-" + hashOf(long)},
+	}
+	for _, tt := range tests {
+		if got := GenerateGitStarName(tt.fullName); got != tt.want {
+			t.Errorf("GenerateGitStarName(%q) = %q, want %q", tt.fullName, got, tt.want)
+		}
+		if got := GenerateGitStarName(tt.fullName); len(got) > maxGitStarNameLength {
+			t.Errorf("GenerateGitStarName(%q) = %q is longer than %d", tt.fullName, got, maxGitStarNameLength)
+		}
+	}
+
+	// distinct repos of an owner get distinct names
+	collisions := [][2]string{
+		{"kuri-su/foo.bar", "kuri-su/foo-bar"},
+		{"kuri-su/foo.bar", "kuri-su/foo_bar"},
+		{"kuri-su/" + strings.Repeat("a", 40) + "-one", "kuri-su/" + strings.Repeat("a", 40) + "-two"},
+	}
+	for _, names := range collisions {
+		if a, b := GenerateGitStarName(names[0]), GenerateGitStarName(names[1]); a == b {
+			t.Errorf("%q and %q are both named %q", names[0], names[1], a)
+		}
+	}
+}
+
+func hashOf(fullName string) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(strings.ToLower(fullName)))
+	return fmt.Sprintf("%08x", hash.Sum32())
+}
+
+func TestFilterRepos(t *testing.T) {
+	repo := func(name string, stars int, fork, archived bool) *github.Repository {
+		return &github.Repository{Name: github.String(name), StargazersCount: github.Int(stars),
+			Fork: github.Bool(fork), Archived: github.Bool(archived)}
+	}
+	repos := []*github.Repository{
+		repo("kblog", 42, false, false),
+		repo("kblog-ui", 3, false, false),
+		repo("forked", 50, true, false),
+		repo("old", 10, false, true),
+		repo("docs", 0, false, false),
+	}
+	tests := []struct {
+		name string
+		spec appv1.GitStarOrgSpec
+		want []string
+	}{
+		{"defaults", appv1.GitStarOrgSpec{}, []string{"kblog", "kblog-ui", "docs"}},
+		{"forks and archived", appv1.GitStarOrgSpec{IncludeForks: true, IncludeArchived: true},
+			[]string{"kblog", "kblog-ui", "forked", "old", "docs"}},
+		{"min stars", appv1.GitStarOrgSpec{MinStars: 3}, []string{"kblog", "kblog-ui"}},
+		{"include", appv1.GitStarOrgSpec{Include: "^kblog"}, []string{"kblog", "kblog-ui"}},
+		{"exclude", appv1.GitStarOrgSpec{Include: "^kblog", Exclude: "-ui$"}, []string{"kblog"}},
+		{"nothing", appv1.GitStarOrgSpec{MinStars: 100}, nil},
+	}
+	for _, tt := range tests {
+		matched, err := filterRepos(&appv1.GitStarOrg{Spec: tt.spec}, repos)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, repo := range matched {
+			got = append(got, repo.GetName())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: matched %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	for _, spec := range []appv1.GitStarOrgSpec{{Include: "("}, {Exclude: "["}} {
+		if _, err := filterRepos(&appv1.GitStarOrg{Spec: spec}, repos); err == nil {
+			t.Errorf("spec %+v has an invalid regex, want error", spec)
+		}
+	}
+}
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSyncGitStars(t *testing.T) {
+	s := newTestScheme(t)
+	org := &appv1.GitStarOrg{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kuri-su", UID: "org-uid"},
+		Spec:       appv1.GitStarOrgSpec{Owner: "kuri-su"},
+	}
+	gitStar := func(name, repoName string) *appv1.GitStar {
+		g := &appv1.GitStar{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{LabelGitStarOrg: org.Name}},
+			Spec:       appv1.GitStarSpec{RepoName: repoName},
+		}
+		if err := controllerutil.SetControllerReference(org, g, s); err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	// kblog.io was named before names were hashed, dropped is no longer matched, the GitStar of other was created by hand
+	other := &appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kuri-su-other"},
+		Spec:       appv1.GitStarSpec{RepoName: "kuri-su/other"},
+	}
+	c := fake.NewFakeClientWithScheme(s, org, gitStar("kuri-su-kblog-io", "kuri-su/kblog.io"), gitStar("kuri-su-dropped", "kuri-su/dropped"), other)
+	r := &ReconcileGitStarOrg{client: c, scheme: s}
+
+	matched := []*github.Repository{
+		{FullName: github.String("kuri-su/kblog.io")},
+		{FullName: github.String("kuri-su/kblog_io")},
+		{FullName: github.String("kuri-su/kblog")},
+		{FullName: github.String("kuri-su/other")},
+	}
+	tracked, conflicts, err := r.syncGitStars(org, matched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tracked != 3 || !reflect.DeepEqual(conflicts, []string{"kuri-su-other"}) {
+		t.Errorf("tracked = %d, conflicts = %v, want 3 and the GitStar of other", tracked, conflicts)
+	}
+
+	list := &appv1.GitStarList{}
+	if err := c.List(context.TODO(), list, client.InNamespace("default")); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, g := range list.Items {
+		got[g.Name] = g.Spec.RepoName
+	}
+	want := map[string]string{
+		"kuri-su-kblog-io":                      "kuri-su/kblog.io",
+		GenerateGitStarName("kuri-su/kblog_io"): "kuri-su/kblog_io",
+		"kuri-su-kblog":                         "kuri-su/kblog",
+		"kuri-su-other":                         "kuri-su/other",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GitStars = %v, want %v", got, want)
+	}
+}
+
+func TestFailureBackoff(t *testing.T) {
+	tests := []struct {
+		failures int32
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, time.Hour},
+		{1000, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := failureBackoff(tt.failures, time.Hour); got != tt.want {
+			t.Errorf("failureBackoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestNextSync(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) metav1.Time { return metav1.NewTime(now.Add(-d)) }
+	tests := []struct {
+		name   string
+		status appv1.GitStarOrgStatus
+		want   time.Duration
+	}{
+		{"never synced", appv1.GitStarOrgStatus{}, 0},
+		{"synced", appv1.GitStarOrgStatus{ObservedGeneration: 1, UpdatedAt: ago(10 * time.Minute)}, 50 * time.Minute},
+		{"sync period over", appv1.GitStarOrgStatus{ObservedGeneration: 1, UpdatedAt: ago(2 * time.Hour)}, 0},
+		{"spec changed", appv1.GitStarOrgStatus{UpdatedAt: ago(10 * time.Minute)}, 0},
+		{"failed", appv1.GitStarOrgStatus{ObservedGeneration: 1, UpdatedAt: ago(2 * time.Hour), FailedReason: "502",
+			FailedAt: ago(30 * time.Second), Failures: 1}, 30 * time.Second},
+		{"failed again", appv1.GitStarOrgStatus{ObservedGeneration: 1, FailedReason: "502",
+			FailedAt: ago(30 * time.Second), Failures: 3}, 3*time.Minute + 30*time.Second},
+		{"backoff over", appv1.GitStarOrgStatus{ObservedGeneration: 1, FailedReason: "502",
+			FailedAt: ago(5 * time.Minute), Failures: 3}, 0},
+	}
+	for _, tt := range tests {
+		if got := nextSync(&tt.status, 1, time.Hour, now); got != tt.want {
+			t.Errorf("%s: next sync in %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReconcileBackoff(t *testing.T) {
+	s := newTestScheme(t)
+	org := &appv1.GitStarOrg{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kuri-su", UID: "org-uid"},
+		Spec:       appv1.GitStarOrgSpec{Owner: "kuri-su"},
+	}
+	c := fake.NewFakeClientWithScheme(s, org)
+	// the token ConfigMap is read around the cache of the manager
+	reader := fake.NewFakeClientWithScheme(s, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gitstar-github-token"},
+		Data:       map[string]string{"token": testToken},
+	})
+	lists := 0
+	var listErr error
+	r := &ReconcileGitStarOrg{client: c, reader: reader, scheme: s, listRepos: func(ctx context.Context, owner, token string) ([]*github.Repository, error) {
+		if _, ok := ctx.Deadline(); !ok || token != testToken {
+			t.Errorf("list repos with deadline %v and token %q, want a deadline and the token", ok, token)
+		}
+		lists++
+		return []*github.Repository{{FullName: github.String("kuri-su/kblog")}}, listErr
+	}}
+	key := types.NamespacedName{Namespace: "default", Name: "kuri-su"}
+	sync := func() (reconcile.Result, *appv1.GitStarOrg) {
+		result, err := r.Reconcile(reconcile.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := &appv1.GitStarOrg{}
+		if err := c.Get(context.TODO(), key, got); err != nil {
+			t.Fatal(err)
+		}
+		return result, got
+	}
+
+	listErr = errors.New("502 Bad Gateway")
+	result, got := sync()
+	if lists != 1 || result.RequeueAfter != time.Minute || got.Status.Failures != 1 || got.Status.FailedReason == "" {
+		t.Fatalf("%d lists, requeue after %v, status %+v, want a failure requeued after 1m", lists, result.RequeueAfter, got.Status)
+	}
+
+	// the status update of an owned GitStar doesn't list the repos again during the backoff
+	result, _ = sync()
+	if lists != 1 || result.RequeueAfter <= 0 || result.RequeueAfter > time.Minute {
+		t.Fatalf("%d lists, requeue after %v, want no list during the backoff", lists, result.RequeueAfter)
+	}
+
+	// the backoff doubles
+	got.Status.FailedAt = metav1.NewTime(time.Now().Add(-time.Minute))
+	if err := c.Status().Update(context.TODO(), got); err != nil {
+		t.Fatal(err)
+	}
+	result, got = sync()
+	if lists != 2 || result.RequeueAfter != 2*time.Minute || got.Status.Failures != 2 {
+		t.Fatalf("%d lists, requeue after %v, status %+v, want a second failure requeued after 2m", lists, result.RequeueAfter, got.Status)
+	}
+
+	// a spec change lists right away and a success resets the failures
+	listErr = nil
+	got.Generation++
+	if err := c.Update(context.TODO(), got); err != nil {
+		t.Fatal(err)
+	}
+	result, got = sync()
+	if lists != 3 || result.RequeueAfter != defaultSyncPeriod || got.Status.Failures != 0 || got.Status.FailedReason != "" ||
+		got.Status.TrackedRepos != 1 {
+		t.Errorf("%d lists, requeue after %v, status %+v, want a success", lists, result.RequeueAfter, got.Status)
+	}
+}
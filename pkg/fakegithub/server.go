@@ -0,0 +1,639 @@
+// Package fakegithub serves a small subset of the GitHub REST API from memory, it is meant to replace
+// api.github.com in tests.
+package fakegithub
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Repo is a repository served by the fake server
+type Repo struct {
+	Owner      string
+	Name       string
+	Stars      int
+	Forks      int
+	OpenIssues int
+	Watchers   int
+	Fork       bool
+	Archived   bool
+	// Stargazers are served oldest first like GitHub does
+	Stargazers []Stargazer
+	// Releases are served as they are, GitHub serves them newest first
+	Releases []Release
+	Tags     []string
+	// Issues are the issues and pull requests of the repo
+	Issues []Issue
+	// Contributors are served with the most commits first by the contributors API
+	Contributors []Contributor
+	// Push tells whether the token has push access to the repo, its traffic is only served then
+	Push bool
+	// Views and Clones are the daily traffic of the repo, Referrers its top referrers
+	Views     []Traffic
+	Clones    []Traffic
+	Referrers []Referrer
+}
+
+// Traffic is the number of views or clones of a repo on a day
+type Traffic struct {
+	Day     time.Time
+	Count   int
+	Uniques int
+}
+
+// Referrer is a site referring visitors to a repo
+type Referrer struct {
+	Referrer string
+	Count    int
+	Uniques  int
+}
+
+// Contributor is a user committing to a repo
+type Contributor struct {
+	Login   string
+	Commits int
+}
+
+// Issue is an issue or a pull request of a repo
+type Issue struct {
+	Number      int
+	Author      string
+	PullRequest bool
+	CreatedAt   time.Time
+	// ClosedAt is zero while the issue is open
+	ClosedAt time.Time
+	Comments []Comment
+}
+
+// Comment is a comment on an issue
+type Comment struct {
+	Author    string
+	Bot       bool
+	CreatedAt time.Time
+}
+
+// Release is a release of a repo
+type Release struct {
+	TagName     string
+	Name        string
+	PublishedAt time.Time
+	Prerelease  bool
+	// Downloads are the download counts of the assets
+	Downloads []int
+}
+
+// Stargazer is a user starring a repo
+type Stargazer struct {
+	Login     string
+	StarredAt time.Time
+}
+
+// FullName returns the repo name like "owner/repo"
+func (r *Repo) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// Server is a fake GitHub API server
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	repos       map[string]*Repo
+	orgs        map[string]bool
+	errors      map[string]int
+	rateLimit   int
+	rateUsed    int
+	requests    []*http.Request
+	etagEnabled bool
+	// computing counts the requests to the contributor statistics of a repo answered with 202 before the statistics
+	computing map[string]int
+}
+
+// NewServer starts a fake GitHub API server, Close it when done
+func NewServer() *Server {
+	s := &Server{
+		repos:       map[string]*Repo{},
+		orgs:        map[string]bool{},
+		errors:      map[string]int{},
+		rateLimit:   -1,
+		etagEnabled: true,
+		computing:   map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddRepo adds or replaces a repo
+func (s *Server) AddRepo(repo Repo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := repo
+	s.repos[r.FullName()] = &r
+}
+
+// SetStars changes the star number of a repo added before
+func (s *Server) SetStars(fullName string, stars int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.repos[fullName]; ok {
+		r.Stars = stars
+	}
+}
+
+// AddOrg marks owner as an organization, owners not marked are served as users
+func (s *Server) AddOrg(owner string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orgs[owner] = true
+}
+
+// SetError makes every request to path, like "/repos/owner/repo", fail with status code, 0 removes the error
+func (s *Server) SetError(path string, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code == 0 {
+		delete(s.errors, path)
+		return
+	}
+	s.errors[path] = code
+}
+
+// SetStatsComputing answers the next n requests to the contributor statistics of a repo with 202 Accepted, like
+// GitHub does while it computes them
+func (s *Server) SetStatsComputing(fullName string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.computing[fullName] = n
+}
+
+// SetRateLimit allows limit more requests before answering 403 rate limit exceeded, a negative limit disables it
+func (s *Server) SetRateLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimit = limit
+	s.rateUsed = 0
+}
+
+// SetETagEnabled turns the ETag headers and the 304 answers to matching If-None-Match headers on or off
+func (s *Server) SetETagEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.etagEnabled = enabled
+}
+
+// Requests returns the requests received so far
+func (s *Server) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*http.Request(nil), s.requests...)
+}
+
+// URL returns the base URL of the API, to be used as the BaseURL of a github.Client
+func (s *Server) URL() string {
+	return s.Server.URL + "/"
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+
+	if s.rateLimit >= 0 {
+		remaining := s.rateLimit - s.rateUsed
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(s.rateLimit))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		if remaining <= 0 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			writeError(w, http.StatusForbidden, "API rate limit exceeded")
+			return
+		}
+		s.rateUsed++
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining-1))
+	}
+
+	if code, ok := s.errors[req.URL.Path]; ok {
+		writeError(w, code, http.StatusText(code))
+		return
+	}
+
+	if req.Method == http.MethodPost && req.URL.Path == "/graphql" {
+		s.serveGraphQL(w, req)
+		return
+	}
+
+	if req.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 3 && parts[0] == "repos":
+		repo, ok := s.repos[parts[1]+"/"+parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		s.writeJSON(w, req, repoJSON(repo))
+	case len(parts) == 4 && parts[0] == "repos" && parts[3] == "stargazers":
+		repo, ok := s.repos[parts[1]+"/"+parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		items := make([]interface{}, 0, len(repo.Stargazers))
+		for _, stargazer := range repo.Stargazers {
+			items = append(items, map[string]interface{}{
+				"starred_at": stargazer.StarredAt.UTC().Format(time.RFC3339),
+				"user":       map[string]interface{}{"login": stargazer.Login},
+			})
+		}
+		s.writeJSON(w, req, paginate(w, req, items))
+	case len(parts) == 4 && parts[0] == "repos" && parts[3] == "releases":
+		repo, ok := s.repos[parts[1]+"/"+parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		items := make([]interface{}, 0, len(repo.Releases))
+		for i, release := range repo.Releases {
+			assets := make([]interface{}, 0, len(release.Downloads))
+			for j, downloads := range release.Downloads {
+				assets = append(assets, map[string]interface{}{"id": j + 1, "download_count": downloads})
+			}
+			items = append(items, map[string]interface{}{
+				"id":           i + 1,
+				"tag_name":     release.TagName,
+				"name":         release.Name,
+				"html_url":     fmt.Sprintf("https://github.com/%s/releases/tag/%s", repo.FullName(), release.TagName),
+				"published_at": release.PublishedAt.UTC().Format(time.RFC3339),
+				"prerelease":   release.Prerelease,
+				"assets":       assets,
+			})
+		}
+		s.writeJSON(w, req, paginate(w, req, items))
+	case len(parts) == 4 && parts[0] == "repos" && parts[3] == "tags":
+		repo, ok := s.repos[parts[1]+"/"+parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		items := make([]interface{}, 0, len(repo.Tags))
+		for _, tag := range repo.Tags {
+			items = append(items, map[string]interface{}{"name": tag})
+		}
+		s.writeJSON(w, req, paginate(w, req, items))
+	case len(parts) == 4 && parts[0] == "repos" && parts[3] == "contributors":
+		repo, ok := s.repos[parts[1]+"/"+parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		contributors := append([]Contributor(nil), repo.Contributors...)
+		sort.SliceStable(contributors, func(i, j int) bool { return contributors[i].Commits > contributors[j].Commits })
+		items := make([]interface{}, 0, len(contributors))
+		for _, contributor := range contributors {
+			items = append(items, map[string]interface{}{"login": contributor.Login, "contributions": contributor.Commits})
+		}
+		s.writeJSON(w, req, paginate(w, req, items))
+	case len(parts) == 5 && parts[0] == "repos" && parts[3] == "stats" && parts[4] == "contributors":
+		repo, ok := s.repos[parts[1]+"/"+parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		if s.computing[repo.FullName()] > 0 {
+			s.computing[repo.FullName()]--
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, "{}")
+			return
+		}
+		// the statistics are served with the fewest commits first
+		contributors := append([]Contributor(nil), repo.Contributors...)
+		sort.SliceStable(contributors, func(i, j int) bool { return contributors[i].Commits < contributors[j].Commits })
+		items := make([]interface{}, 0, len(contributors))
+		for _, contributor := range contributors {
+			items = append(items, map[string]interface{}{
+				"author": map[string]interface{}{"login": contributor.Login},
+				"total":  contributor.Commits,
+				"weeks":  []interface{}{},
+			})
+		}
+		s.writeJSON(w, req, items)
+	case len(parts) >= 5 && parts[0] == "repos" && parts[3] == "traffic":
+		repo, ok := s.repos[parts[1]+"/"+parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		if !repo.Push {
+			writeError(w, http.StatusForbidden, "Must have push access to repository")
+			return
+		}
+		switch strings.Join(parts[4:], "/") {
+		case "views":
+			s.writeJSON(w, req, trafficJSON("views", repo.Views))
+		case "clones":
+			s.writeJSON(w, req, trafficJSON("clones", repo.Clones))
+		case "popular/referrers":
+			items := make([]interface{}, 0, len(repo.Referrers))
+			for _, referrer := range repo.Referrers {
+				items = append(items, map[string]interface{}{"referrer": referrer.Referrer, "count": referrer.Count, "uniques": referrer.Uniques})
+			}
+			s.writeJSON(w, req, items)
+		default:
+			writeError(w, http.StatusNotFound, "Not Found")
+		}
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "issues" && parts[5] == "comments":
+		repo, ok := s.repos[parts[1]+"/"+parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		number, _ := strconv.Atoi(parts[4])
+		var issue *Issue
+		for i := range repo.Issues {
+			if repo.Issues[i].Number == number {
+				issue = &repo.Issues[i]
+			}
+		}
+		if issue == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		items := make([]interface{}, 0, len(issue.Comments))
+		for i, comment := range issue.Comments {
+			userType := "User"
+			if comment.Bot {
+				userType = "Bot"
+			}
+			items = append(items, map[string]interface{}{
+				"id":         i + 1,
+				"user":       map[string]interface{}{"login": comment.Author, "type": userType},
+				"created_at": comment.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+		s.writeJSON(w, req, paginate(w, req, items))
+	case len(parts) == 2 && parts[0] == "search" && parts[1] == "issues":
+		s.writeIssueSearch(w, req)
+	case len(parts) == 3 && parts[0] == "orgs" && parts[2] == "repos":
+		if !s.orgs[parts[1]] {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		s.writeRepoPage(w, req, parts[1])
+	case len(parts) == 3 && parts[0] == "users" && parts[2] == "repos":
+		s.writeRepoPage(w, req, parts[1])
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+// serveGraphQL answers the query of the newest 100 stargazers of a repo, the only GraphQL query the fetchers send
+func (s *Server) serveGraphQL(w http.ResponseWriter, req *http.Request) {
+	var query struct {
+		Variables struct {
+			Owner string `json:"owner"`
+			Name  string `json:"name"`
+		} `json:"variables"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	repo, ok := s.repos[query.Variables.Owner+"/"+query.Variables.Name]
+	if !ok {
+		s.writeJSON(w, req, map[string]interface{}{
+			"data":   map[string]interface{}{"repository": nil},
+			"errors": []interface{}{map[string]string{"message": "Could not resolve to a Repository"}},
+		})
+		return
+	}
+	newest := repo.Stargazers
+	if len(newest) > 100 {
+		newest = newest[len(newest)-100:]
+	}
+	edges := make([]interface{}, 0, len(newest))
+	for _, stargazer := range newest {
+		edges = append(edges, map[string]interface{}{"starredAt": stargazer.StarredAt.UTC().Format(time.RFC3339)})
+	}
+	s.writeJSON(w, req, map[string]interface{}{
+		"data": map[string]interface{}{
+			"repository": map[string]interface{}{
+				"stargazers": map[string]interface{}{"totalCount": len(repo.Stargazers), "edges": edges},
+			},
+		},
+	})
+}
+
+// writeIssueSearch answers the search of issues with the qualifiers repo:, is:pr, is:issue, is:open, is:closed,
+// created:>= and closed:>=, newest first
+func (s *Server) writeIssueSearch(w http.ResponseWriter, req *http.Request) {
+	repos := map[string]bool{}
+	var filters []func(issue *Issue) bool
+	for _, term := range strings.Fields(req.URL.Query().Get("q")) {
+		switch {
+		case strings.HasPrefix(term, "repo:"):
+			repos[strings.TrimPrefix(term, "repo:")] = true
+		case term == "is:pr":
+			filters = append(filters, func(issue *Issue) bool { return issue.PullRequest })
+		case term == "is:issue":
+			filters = append(filters, func(issue *Issue) bool { return !issue.PullRequest })
+		case term == "is:open":
+			filters = append(filters, func(issue *Issue) bool { return issue.ClosedAt.IsZero() })
+		case term == "is:closed":
+			filters = append(filters, func(issue *Issue) bool { return !issue.ClosedAt.IsZero() })
+		case strings.HasPrefix(term, "created:>="), strings.HasPrefix(term, "closed:>="):
+			since, err := time.Parse("2006-01-02", term[strings.Index(term, ">=")+2:])
+			if err != nil {
+				writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+				return
+			}
+			closed := strings.HasPrefix(term, "closed:")
+			filters = append(filters, func(issue *Issue) bool {
+				if closed {
+					return !issue.ClosedAt.IsZero() && !issue.ClosedAt.Before(since)
+				}
+				return !issue.CreatedAt.Before(since)
+			})
+		default:
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+	}
+
+	type match struct {
+		repo  *Repo
+		issue *Issue
+	}
+	var matches []match
+	for name := range repos {
+		repo, ok := s.repos[name]
+		if !ok {
+			continue
+		}
+	issues:
+		for i := range repo.Issues {
+			for _, filter := range filters {
+				if !filter(&repo.Issues[i]) {
+					continue issues
+				}
+			}
+			matches = append(matches, match{repo: repo, issue: &repo.Issues[i]})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].issue.CreatedAt.After(matches[j].issue.CreatedAt)
+	})
+
+	items := make([]interface{}, 0, len(matches))
+	for _, m := range matches {
+		item := map[string]interface{}{
+			"number":         m.issue.Number,
+			"user":           map[string]interface{}{"login": m.issue.Author},
+			"created_at":     m.issue.CreatedAt.UTC().Format(time.RFC3339),
+			"comments":       len(m.issue.Comments),
+			"repository_url": fmt.Sprintf("http://%s/repos/%s", req.Host, m.repo.FullName()),
+			"state":          "open",
+		}
+		if !m.issue.ClosedAt.IsZero() {
+			item["state"] = "closed"
+			item["closed_at"] = m.issue.ClosedAt.UTC().Format(time.RFC3339)
+		}
+		if m.issue.PullRequest {
+			item["pull_request"] = map[string]interface{}{"url": fmt.Sprintf("http://%s/repos/%s/pulls/%d", req.Host, m.repo.FullName(), m.issue.Number)}
+		}
+		items = append(items, item)
+	}
+	s.writeJSON(w, req, map[string]interface{}{
+		"total_count":        len(items),
+		"incomplete_results": false,
+		"items":              paginate(w, req, items),
+	})
+}
+
+// writeRepoPage writes the page of repos of owner asked by the page and per_page parameters
+func (s *Server) writeRepoPage(w http.ResponseWriter, req *http.Request, owner string) {
+	var names []string
+	for name, repo := range s.repos {
+		if repo.Owner == owner {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	items := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		items = append(items, repoJSON(s.repos[name]))
+	}
+	s.writeJSON(w, req, paginate(w, req, items))
+}
+
+// writeJSON writes v with an ETag, or 304 when the client already has it
+func (s *Server) writeJSON(w http.ResponseWriter, req *http.Request, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if s.etagEnabled {
+		etag := fmt.Sprintf(`"%x"`, sha1.Sum(data))
+		w.Header().Set("ETag", etag)
+		if req.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// paginate returns the items of the requested page and sets the Link header of the next and the last page
+func paginate(w http.ResponseWriter, req *http.Request, items []interface{}) []interface{} {
+	perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page"))
+	if perPage <= 0 {
+		perPage = 30
+	}
+	page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	start := (page - 1) * perPage
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + perPage
+	if end > len(items) {
+		end = len(items)
+	}
+
+	if end < len(items) {
+		lastPage := (len(items) + perPage - 1) / perPage
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next", <%s>; rel="last"`,
+			pageURL(req, page+1), pageURL(req, lastPage)))
+	}
+	return items[start:end]
+}
+
+func pageURL(req *http.Request, page int) string {
+	u := *req.URL
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf("http://%s%s", req.Host, u.RequestURI())
+}
+
+func repoJSON(r *Repo) map[string]interface{} {
+	return map[string]interface{}{
+		"name":              r.Name,
+		"full_name":         r.FullName(),
+		"owner":             map[string]interface{}{"login": r.Owner},
+		"stargazers_count":  r.Stars,
+		"watchers_count":    r.Stars,
+		"forks_count":       r.Forks,
+		"open_issues_count": r.OpenIssues,
+		"subscribers_count": r.Watchers,
+		"fork":              r.Fork,
+		"archived":          r.Archived,
+		"permissions":       map[string]interface{}{"admin": false, "push": r.Push, "pull": true},
+	}
+}
+
+// trafficJSON serves the daily views or clones of a repo under key with their totals
+func trafficJSON(key string, days []Traffic) map[string]interface{} {
+	count, uniques := 0, 0
+	items := make([]interface{}, 0, len(days))
+	for _, day := range days {
+		count += day.Count
+		uniques += day.Uniques
+		items = append(items, map[string]interface{}{
+			"timestamp": day.Day.UTC().Format(time.RFC3339),
+			"count":     day.Count,
+			"uniques":   day.Uniques,
+		})
+	}
+	return map[string]interface{}{"count": count, "uniques": uniques, key: items}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"message":           message,
+		"documentation_url": "https://developer.github.com/v3",
+	})
+}
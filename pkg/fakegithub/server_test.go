@@ -0,0 +1,34 @@
+package fakegithub
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeIssueComments(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddRepo(Repo{Owner: "kuri-su", Name: "kblog", Issues: []Issue{
+		{Number: 1, Author: "alice", CreatedAt: time.Now(), Comments: []Comment{{Author: "kuri-su", CreatedAt: time.Now()}}},
+	}})
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path: "/repos/kuri-su/kblog/issues/1/comments", want: http.StatusOK},
+		{path: "/repos/kuri-su/kblog/issues/2/comments", want: http.StatusNotFound},
+		{path: "/repos/kuri-su/unknown/issues/1/comments", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(s.Server.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package gitOperation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+const (
+	// ActivityWindow is the window the opened and closed issues and the first responses are counted in
+	ActivityWindow = 30 * 24 * time.Hour
+
+	// maxResponseSamples caps the newest issues and pull requests whose comments are read for the first response
+	maxResponseSamples = 30
+)
+
+// Activity is the pull request and issue activity of repos
+type Activity struct {
+	OpenPullRequests   int64
+	ClosedPullRequests int64
+	// IssuesOpened and IssuesClosed count the issues opened and closed since the start of the window
+	IssuesOpened int64
+	IssuesClosed int64
+	// MedianFirstResponse is computed from Responses issues and pull requests, 0 when there is none
+	MedianFirstResponse time.Duration
+	Responses           int64
+}
+
+// ActivityFetcher fetches the activity of repos, a RepoStatsFetcher may implement it
+type ActivityFetcher interface {
+	FetchActivity(ctx context.Context, repoNames []string, since time.Time) (*Activity, error)
+}
+
+// blank assignment to verify that GitHubFetcher implements ActivityFetcher
+var _ ActivityFetcher = &GitHubFetcher{}
+
+// FetchActivity fetches the activity of repos named like "owner/repo" since a day with the search API, one search
+// covers all repos. The first response is the first comment of someone else than the author which isn't a bot.
+func (f *GitHubFetcher) FetchActivity(ctx context.Context, repoNames []string, since time.Time) (*Activity, error) {
+	var qualifiers []string
+	for _, repoName := range repoNames {
+		if _, _, err := splitRepoName(repoName); err != nil {
+			return nil, err
+		}
+		qualifiers = append(qualifiers, "repo:"+repoName)
+	}
+	scope := strings.Join(qualifiers, " ")
+	day := since.UTC().Format("2006-01-02")
+
+	activity := &Activity{}
+	counts := []struct {
+		query string
+		count *int64
+	}{
+		{query: "is:pr is:open", count: &activity.OpenPullRequests},
+		{query: "is:pr is:closed", count: &activity.ClosedPullRequests},
+		{query: "is:issue created:>=" + day, count: &activity.IssuesOpened},
+		{query: "is:issue closed:>=" + day, count: &activity.IssuesClosed},
+	}
+	for _, c := range counts {
+		result, _, err := f.client.Search.Issues(ctx, scope+" "+c.query, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 1}})
+		if err != nil {
+			return nil, err
+		}
+		*c.count = int64(result.GetTotal())
+	}
+
+	recent, _, err := f.client.Search.Issues(ctx, scope+" created:>="+day, &github.SearchOptions{
+		Sort:        "created",
+		Order:       "desc",
+		ListOptions: github.ListOptions{PerPage: maxResponseSamples},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var responses []time.Duration
+	for i := range recent.Issues {
+		response, ok, err := f.firstResponse(ctx, &recent.Issues[i])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			responses = append(responses, response)
+		}
+	}
+	activity.MedianFirstResponse = medianDuration(responses)
+	activity.Responses = int64(len(responses))
+	return activity, nil
+}
+
+// firstResponse returns the time from the opening of an issue to its first comment of someone else than the author
+func (f *GitHubFetcher) firstResponse(ctx context.Context, issue *github.Issue) (time.Duration, bool, error) {
+	if issue.GetComments() == 0 {
+		return 0, false, nil
+	}
+	owner, repo, err := repoOfIssue(issue)
+	if err != nil {
+		return 0, false, err
+	}
+	comments, _, err := f.client.Issues.ListComments(ctx, owner, repo, issue.GetNumber(),
+		&github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 30}})
+	if err != nil {
+		return 0, false, err
+	}
+	for _, comment := range comments {
+		if comment.GetUser().GetLogin() != issue.GetUser().GetLogin() && comment.GetUser().GetType() != "Bot" {
+			return comment.GetCreatedAt().Sub(issue.GetCreatedAt()), true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// repoOfIssue returns the repo of an issue found by a search from its repository_url, like .../repos/owner/repo
+func repoOfIssue(issue *github.Issue) (string, string, error) {
+	u, err := url.Parse(issue.GetRepositoryURL())
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("repository url '%s' is invalid", issue.GetRepositoryURL())
+	}
+	return parts[len(parts)-2], parts[len(parts)-1], nil
+}
+
+// medianDuration returns the median of durations, 0 when there is none
+func medianDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// refreshActivity reports the pull request and issue activity of the repos of gitStar in status.activity, a failure
+// keeps the previous report
+func (r *Runner) refreshActivity(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) {
+	if !gitStar.Spec.TrackActivity {
+		gitStar.Status.Activity = nil
+		return
+	}
+
+	status, err := fetchActivity(ctx, gitStar, fetcher)
+	if err != nil {
+		log.Error(err, "fetch activity failed! ", "Request.Namespace", gitStar.Namespace, "Request.Name", gitStar.Name)
+		status = gitStar.Status.Activity.DeepCopy()
+		if status == nil {
+			status = &appV1.GitStarActivityStatus{}
+		}
+		status.FailedReason = err.Error()
+	}
+	gitStar.Status.Activity = status
+}
+
+func fetchActivity(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) (*appV1.GitStarActivityStatus, error) {
+	activityFetcher, ok := fetcher.(ActivityFetcher)
+	if !ok {
+		return nil, errors.New("the fetcher can't fetch the activity")
+	}
+
+	now := time.Now()
+	activity, err := activityFetcher.FetchActivity(ctx, gitStar.Spec.RepoNames(), now.Add(-ActivityWindow))
+	if err != nil {
+		return nil, err
+	}
+	status := &appV1.GitStarActivityStatus{
+		OpenPullRequests:   activity.OpenPullRequests,
+		ClosedPullRequests: activity.ClosedPullRequests,
+		IssuesOpened:       activity.IssuesOpened,
+		IssuesClosed:       activity.IssuesClosed,
+		Responses:          activity.Responses,
+		UpdatedAt:          metav1.NewTime(now),
+	}
+	if activity.Responses > 0 {
+		status.MedianFirstResponse = &metav1.Duration{Duration: activity.MedianFirstResponse}
+	}
+	return status, nil
+}
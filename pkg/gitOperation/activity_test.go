@@ -0,0 +1,114 @@
+package gitOperation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/fakegithub"
+)
+
+// newTestIssues returns issues and pull requests relative to now
+func newTestIssues(now time.Time) []fakegithub.Issue {
+	day := 24 * time.Hour
+	return []fakegithub.Issue{
+		// answered after 2h by a maintainer, the bot doesn't count
+		{Number: 1, Author: "alice", CreatedAt: now.Add(-2 * day), Comments: []fakegithub.Comment{
+			{Author: "ci-bot", Bot: true, CreatedAt: now.Add(-2*day + time.Minute)},
+			{Author: "alice", CreatedAt: now.Add(-2*day + time.Hour)},
+			{Author: "kuri-su", CreatedAt: now.Add(-2*day + 2*time.Hour)},
+		}},
+		// answered after 4h, closed
+		{Number: 2, Author: "bob", CreatedAt: now.Add(-3 * day), ClosedAt: now.Add(-day), Comments: []fakegithub.Comment{
+			{Author: "kuri-su", CreatedAt: now.Add(-3*day + 4*time.Hour)},
+		}},
+		// not answered
+		{Number: 3, Author: "carol", CreatedAt: now.Add(-4 * day)},
+		// opened before the window, closed in it
+		{Number: 4, Author: "dave", CreatedAt: now.Add(-60 * day), ClosedAt: now.Add(-5 * day)},
+		// pull requests
+		{Number: 5, Author: "erin", PullRequest: true, CreatedAt: now.Add(-day), Comments: []fakegithub.Comment{
+			{Author: "kuri-su", CreatedAt: now.Add(-day + 12*time.Hour)},
+		}},
+		{Number: 6, Author: "frank", PullRequest: true, CreatedAt: now.Add(-90 * day), ClosedAt: now.Add(-80 * day)},
+	}
+}
+
+func TestFetchActivity(t *testing.T) {
+	now := time.Now()
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Issues: newTestIssues(now)})
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog-ui", Issues: []fakegithub.Issue{
+		{Number: 1, Author: "grace", PullRequest: true, CreatedAt: now.Add(-time.Hour)},
+	}})
+
+	f := newTestFetcher(t, server).(*GitHubFetcher)
+	activity, err := f.FetchActivity(context.TODO(), []string{"kuri-su/kblog", "kuri-su/kblog-ui"}, now.Add(-ActivityWindow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Activity{
+		OpenPullRequests:    2,
+		ClosedPullRequests:  1,
+		IssuesOpened:        3,
+		IssuesClosed:        2,
+		MedianFirstResponse: 4 * time.Hour,
+		Responses:           3,
+	}
+	if *activity != want {
+		t.Errorf("activity = %+v, want %+v", *activity, want)
+	}
+
+	if _, err := f.FetchActivity(context.TODO(), []string{"invalid"}, now); err == nil {
+		t.Error("invalid repo name, want error")
+	}
+}
+
+func TestMedianDuration(t *testing.T) {
+	tests := []struct {
+		durations []time.Duration
+		want      time.Duration
+	}{
+		{durations: nil, want: 0},
+		{durations: []time.Duration{3, 1, 2}, want: 2},
+		{durations: []time.Duration{4, 1, 3, 2}, want: 2},
+	}
+	for _, tt := range tests {
+		if got := medianDuration(tt.durations); got != tt.want {
+			t.Errorf("medianDuration(%v) = %v, want %v", tt.durations, got, tt.want)
+		}
+	}
+}
+
+func TestRunnerTrackActivity(t *testing.T) {
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42, Issues: newTestIssues(time.Now())})
+	factory, err := GitHubFetcherFactoryForURL(server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitStar := newTestGitStar("default", "kblog", "kuri-su/kblog")
+	gitStar.Spec.TrackActivity = true
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), gitStar)
+	runner := &Runner{Client: c, NewFetcher: factory}
+	if err := runner.Run("default", "kblog"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &appV1.GitStar{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog"}, got); err != nil {
+		t.Fatal(err)
+	}
+	activity := got.Status.Activity
+	if activity == nil || activity.OpenPullRequests != 1 || activity.IssuesOpened != 3 ||
+		activity.MedianFirstResponse == nil || activity.MedianFirstResponse.Duration != 4*time.Hour || activity.FailedReason != "" {
+		t.Errorf("activity = %+v, want 1 open pull request, 3 opened issues and a median first response of 4h", activity)
+	}
+}
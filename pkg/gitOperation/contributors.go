@@ -0,0 +1,181 @@
+package gitOperation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+const (
+	// DefaultTopContributors is the number of contributors reported unless spec.topContributors is set
+	DefaultTopContributors = 10
+	// maxTopContributors is the number of contributors GitHub computes the statistics of
+	maxTopContributors = 100
+
+	// contributorStatsAttempts bounds the requests waiting for GitHub to compute the contributor statistics of a repo
+	contributorStatsAttempts = 5
+)
+
+// contributorStatsRetryWait is the wait before the first retry of the contributor statistics, it doubles with every
+// retry
+var contributorStatsRetryWait = time.Second
+
+// Contributor is a user with the commits to the default branch of a repo
+type Contributor struct {
+	Login   string
+	Commits int64
+}
+
+// ContributorStats are the contributors of a repo
+type ContributorStats struct {
+	// Count is the number of contributors, anonymous ones aside
+	Count int64
+	// Top are the contributors GitHub computes the statistics of, most commits first
+	Top []Contributor
+}
+
+// ContributorFetcher fetches the contributors of a repo, a RepoStatsFetcher may implement it
+type ContributorFetcher interface {
+	FetchContributorStats(ctx context.Context, repoName string) (*ContributorStats, error)
+}
+
+// blank assignment to verify that GitHubFetcher implements ContributorFetcher
+var _ ContributorFetcher = &GitHubFetcher{}
+
+// FetchContributorStats counts the contributors of a repo named like "owner/repo" and reads the commits of the top
+// ones from the contributor statistics, retrying while GitHub computes them
+func (f *GitHubFetcher) FetchContributorStats(ctx context.Context, repoName string) (*ContributorStats, error) {
+	owner, repo, err := splitRepoName(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	// with one contributor per page the last page is the number of contributors
+	contributors, resp, err := f.client.Repositories.ListContributors(ctx, owner, repo,
+		&github.ListContributorsOptions{ListOptions: github.ListOptions{PerPage: 1}})
+	if err != nil {
+		return nil, err
+	}
+	stats := &ContributorStats{Count: int64(len(contributors))}
+	if resp.LastPage > 0 {
+		stats.Count = int64(resp.LastPage)
+	}
+
+	top, err := f.contributorStats(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range top {
+		if login := s.GetAuthor().GetLogin(); login != "" {
+			stats.Top = append(stats.Top, Contributor{Login: login, Commits: int64(s.GetTotal())})
+		}
+	}
+	sortContributors(stats.Top)
+	return stats, nil
+}
+
+// contributorStats lists the contributor statistics of a repo. GitHub answers 202 Accepted while it computes them,
+// go-github reports it as a decoding error of the empty body, so the status code is checked.
+func (f *GitHubFetcher) contributorStats(ctx context.Context, owner, repo string) ([]*github.ContributorStats, error) {
+	wait := contributorStatsRetryWait
+	for attempt := 1; ; attempt++ {
+		stats, resp, err := f.client.Repositories.ListContributorsStats(ctx, owner, repo)
+		if resp == nil || resp.StatusCode != http.StatusAccepted {
+			return stats, err
+		}
+		if attempt == contributorStatsAttempts {
+			return nil, fmt.Errorf("GitHub is still computing the contributor statistics of %s/%s", owner, repo)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// sortContributors sorts contributors by commits, most first, then by login
+func sortContributors(contributors []Contributor) {
+	sort.Slice(contributors, func(i, j int) bool {
+		if contributors[i].Commits != contributors[j].Commits {
+			return contributors[i].Commits > contributors[j].Commits
+		}
+		return contributors[i].Login < contributors[j].Login
+	})
+}
+
+// refreshContributors reports the contributors of the repos of gitStar in status.contributors, a failure keeps the
+// previous report
+func (r *Runner) refreshContributors(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) {
+	if !gitStar.Spec.TrackContributors {
+		gitStar.Status.Contributors = nil
+		return
+	}
+
+	status, err := fetchContributors(ctx, gitStar, fetcher)
+	if err != nil {
+		log.Error(err, "fetch contributors failed! ", "Request.Namespace", gitStar.Namespace, "Request.Name", gitStar.Name)
+		status = gitStar.Status.Contributors.DeepCopy()
+		if status == nil {
+			status = &appV1.GitStarContributorsStatus{}
+		}
+		status.FailedReason = err.Error()
+	}
+	gitStar.Status.Contributors = status
+}
+
+func fetchContributors(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) (*appV1.GitStarContributorsStatus, error) {
+	contributorFetcher, ok := fetcher.(ContributorFetcher)
+	if !ok {
+		return nil, errors.New("the fetcher can't fetch contributors")
+	}
+	limit := DefaultTopContributors
+	if gitStar.Spec.TopContributors > 0 {
+		limit = int(gitStar.Spec.TopContributors)
+	}
+	if limit > maxTopContributors {
+		limit = maxTopContributors
+	}
+
+	status := &appV1.GitStarContributorsStatus{UpdatedAt: metav1.NewTime(time.Now())}
+	commits := map[string]int64{}
+	var failures []string
+	for _, repoName := range gitStar.Spec.RepoNames() {
+		stats, err := contributorFetcher.FetchContributorStats(ctx, repoName)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", repoName, err))
+			continue
+		}
+		status.Count += stats.Count
+		for _, contributor := range stats.Top {
+			commits[contributor.Login] += contributor.Commits
+		}
+	}
+	// the totals of some repos would look like a drop
+	if len(failures) > 0 {
+		return nil, errors.New(strings.Join(failures, "; "))
+	}
+
+	top := make([]Contributor, 0, len(commits))
+	for login, n := range commits {
+		top = append(top, Contributor{Login: login, Commits: n})
+	}
+	sortContributors(top)
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	for _, contributor := range top {
+		status.Top = append(status.Top, appV1.GitStarContributor{Login: contributor.Login, Commits: contributor.Commits})
+	}
+	return status, nil
+}
@@ -0,0 +1,105 @@
+package gitOperation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/fakegithub"
+)
+
+func newTestContributors() []fakegithub.Contributor {
+	return []fakegithub.Contributor{
+		{Login: "bob", Commits: 5},
+		{Login: "kuri-su", Commits: 120},
+		{Login: "alice", Commits: 5},
+	}
+}
+
+// fastContributorStats makes the retries of the contributor statistics fast and lifts the limits of the fetchers
+// created afterwards, call the returned func when done
+func fastContributorStats() func() {
+	wait := contributorStatsRetryWait
+	contributorStatsRetryWait = time.Millisecond
+	SetFetchLimits(FetchLimits{})
+	return func() {
+		contributorStatsRetryWait = wait
+		SetFetchLimits(DefaultFetchLimits())
+	}
+}
+
+func TestFetchContributorStats(t *testing.T) {
+	defer fastContributorStats()()
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Contributors: newTestContributors()})
+
+	f := newTestFetcher(t, server).(*GitHubFetcher)
+	want := &ContributorStats{Count: 3, Top: []Contributor{{"kuri-su", 120}, {"alice", 5}, {"bob", 5}}}
+
+	// the statistics are read once GitHub computed them
+	server.SetStatsComputing("kuri-su/kblog", contributorStatsAttempts-1)
+	stats, err := f.FetchContributorStats(context.TODO(), "kuri-su/kblog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+
+	server.SetStatsComputing("kuri-su/kblog", contributorStatsAttempts)
+	if _, err := f.FetchContributorStats(context.TODO(), "kuri-su/kblog"); err == nil {
+		t.Error("statistics still computed, want error")
+	}
+}
+
+func TestRunnerTrackContributors(t *testing.T) {
+	defer fastContributorStats()()
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42, Contributors: newTestContributors()})
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog-ui", Stars: 3, Contributors: []fakegithub.Contributor{
+		{Login: "bob", Commits: 30},
+		{Login: "carol", Commits: 1},
+	}})
+	factory, err := GitHubFetcherFactoryForURL(server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitStar := newTestGitStar("default", "kblog", "kuri-su/kblog")
+	gitStar.Spec.Repositories = []string{"kuri-su/kblog-ui"}
+	gitStar.Spec.TrackContributors = true
+	gitStar.Spec.TopContributors = 2
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), gitStar)
+	runner := &Runner{Client: c, NewFetcher: factory}
+	run := func() *appV1.GitStarContributorsStatus {
+		if err := runner.Run("default", "kblog"); err != nil {
+			t.Fatal(err)
+		}
+		got := &appV1.GitStar{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog"}, got); err != nil {
+			t.Fatal(err)
+		}
+		return got.Status.Contributors
+	}
+
+	// the commits of bob to both repos are summed
+	status := run()
+	want := []appV1.GitStarContributor{{Login: "kuri-su", Commits: 120}, {Login: "bob", Commits: 35}}
+	if status == nil || status.Count != 5 || !reflect.DeepEqual(status.Top, want) || status.FailedReason != "" {
+		t.Fatalf("contributors = %+v, want 5 contributors and top %v", status, want)
+	}
+
+	// a failed fetch keeps the contributors
+	server.SetError("/repos/kuri-su/kblog-ui/stats/contributors", 502)
+	status = run()
+	if status.Count != 5 || !reflect.DeepEqual(status.Top, want) || status.FailedReason == "" {
+		t.Errorf("contributors = %+v, want the previous contributors with the failure", status)
+	}
+}
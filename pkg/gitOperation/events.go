@@ -0,0 +1,50 @@
+package gitOperation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+// EventSource is the component of the Events recorded about GitStars
+const EventSource = "gitstar-operator"
+
+// recordEvent records an Event about gitStar, the Runner runs in the queryJob too, so the Event is created with its
+// client instead of an EventRecorder. A failure is only logged, and nothing is recorded in a dry run.
+func (r *Runner) recordEvent(ctx context.Context, gitStar *appV1.GitStar, eventType, reason, message string) {
+	if r.DryRun {
+		log.Info(fmt.Sprintf("dry run, skip event %s: %s", reason, message))
+		return
+	}
+
+	now := metav1.NewTime(time.Now())
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x", gitStar.Name, now.UnixNano()),
+			Namespace: gitStar.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion:      appV1.SchemeGroupVersion.String(),
+			Kind:            "GitStar",
+			Namespace:       gitStar.Namespace,
+			Name:            gitStar.Name,
+			UID:             gitStar.UID,
+			ResourceVersion: gitStar.ResourceVersion,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: EventSource},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if err := r.Client.Create(ctx, event); err != nil {
+		log.Error(err, "create event failed! ", "Reason", reason)
+	}
+}
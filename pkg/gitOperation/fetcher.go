@@ -0,0 +1,149 @@
+package gitOperation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// RepoStats is a snapshot of the counters of a repo
+type RepoStats struct {
+	StarNumber      int64
+	ForkNumber      int64
+	OpenIssueNumber int64
+	WatcherNumber   int64
+}
+
+// RepoStatsFetcher fetches the counters of a repo named like "owner/repo" from a provider
+type RepoStatsFetcher interface {
+	FetchRepoStats(ctx context.Context, repoName string) (*RepoStats, error)
+}
+
+// FetcherFactory creates a RepoStatsFetcher authenticated with gitHubOAuthToken, "" means anonymous
+type FetcherFactory func(gitHubOAuthToken string) RepoStatsFetcher
+
+// DefaultFetcherFactory creates fetchers talking to the public GitHub API
+func DefaultFetcherFactory(gitHubOAuthToken string) RepoStatsFetcher {
+	return NewGitHubFetcher(gitHubOAuthToken)
+}
+
+// blank assignment to verify that GitHubFetcher implements RepoStatsFetcher
+var _ RepoStatsFetcher = &GitHubFetcher{}
+
+// GitHubFetcher is the RepoStatsFetcher of GitHub
+type GitHubFetcher struct {
+	client *github.Client
+	// authenticated tells whether the client has a token, GraphQL needs one
+	authenticated bool
+}
+
+// NewGitHubFetcher creates a GitHubFetcher for the public GitHub API
+func NewGitHubFetcher(gitHubOAuthToken string) *GitHubFetcher {
+	return &GitHubFetcher{client: newGitHubClient(gitHubOAuthToken), authenticated: gitHubOAuthToken != ""}
+}
+
+// NewGitHubFetcherForURL creates a GitHubFetcher for the GitHub API served at baseURL,
+// e.g. a GitHub Enterprise server or a fake server in tests
+func NewGitHubFetcherForURL(gitHubOAuthToken, baseURL string) (*GitHubFetcher, error) {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	c := newGitHubClient(gitHubOAuthToken)
+	c.BaseURL = u
+	return &GitHubFetcher{client: c, authenticated: gitHubOAuthToken != ""}, nil
+}
+
+// GitHubFetcherFactoryForURL returns a FetcherFactory creating GitHubFetchers for the GitHub API served at baseURL
+func GitHubFetcherFactoryForURL(baseURL string) (FetcherFactory, error) {
+	if _, err := NewGitHubFetcherForURL("", baseURL); err != nil {
+		return nil, err
+	}
+	return func(gitHubOAuthToken string) RepoStatsFetcher {
+		f, _ := NewGitHubFetcherForURL(gitHubOAuthToken, baseURL)
+		return f
+	}, nil
+}
+
+// FetchRepoStats fetches the counters of a repo named like "owner/repo"
+func (f *GitHubFetcher) FetchRepoStats(ctx context.Context, repoName string) (*RepoStats, error) {
+	owner, repo, err := splitRepoName(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	get, _, err := f.client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	} else if get == nil {
+		return nil, errors.New(fmt.Sprintf("repo : '%s' not found , please check! ", repoName))
+	} else if get.StargazersCount == nil {
+		return nil, errors.New(fmt.Sprintf("repo : '%s' star number is nil , please check! ", repoName))
+	}
+
+	return &RepoStats{
+		StarNumber:      int64(*get.StargazersCount),
+		ForkNumber:      int64(get.GetForksCount()),
+		OpenIssueNumber: int64(get.GetOpenIssuesCount()),
+		WatcherNumber:   int64(get.GetSubscribersCount()),
+	}, nil
+}
+
+// ListRepos lists all repositories of a GitHub organization, falling back to a user when no organization is found
+func (f *GitHubFetcher) ListRepos(ctx context.Context, owner string) ([]*github.Repository, error) {
+	if owner == "" {
+		return nil, errors.New("The owner is empty, please check! ")
+	}
+
+	var repos []*github.Repository
+	orgOpt := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := f.client.Repositories.ListByOrg(ctx, owner, orgOpt)
+		if err != nil {
+			if resp != nil && resp.StatusCode == 404 && len(repos) == 0 {
+				return f.listReposOfUser(ctx, owner)
+			}
+			return nil, err
+		}
+		repos = append(repos, page...)
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		orgOpt.Page = resp.NextPage
+	}
+}
+
+func (f *GitHubFetcher) listReposOfUser(ctx context.Context, user string) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	opt := &github.RepositoryListOptions{Type: "owner", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := f.client.Repositories.List(ctx, user, opt)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, page...)
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func splitRepoName(repoName string) (string, string, error) {
+	split := strings.Split(repoName, "/")
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return "", "", errors.New("The repo name is invalid, please check! ")
+	}
+	return split[0], split[1], nil
+}
+
+func newGitHubClient(gitHubOAuthToken string) *github.Client {
+	return github.NewClient(newHTTPClient(gitHubOAuthToken))
+}
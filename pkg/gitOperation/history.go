@@ -0,0 +1,361 @@
+package gitOperation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/resource"
+)
+
+const (
+	// HistoryCompanion is the kind of the companion ConfigMap keeping the star curves of a GitStar
+	HistoryCompanion = "history"
+
+	// DefaultBackfillRequestLimit caps the requests backfilling a repo unless spec.backfillRequestLimit is set
+	DefaultBackfillRequestLimit = 50
+
+	newestStargazersQuery = `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    stargazers(last: 100, orderBy: {field: STARRED_AT, direction: ASC}) {
+      totalCount
+      edges { starredAt }
+    }
+  }
+}`
+)
+
+// StarPoint is the star number of a repo at a point in time
+type StarPoint struct {
+	Time  time.Time
+	Stars int64
+}
+
+// StarHistoryBackfiller reconstructs the star curve of a repo, a RepoStatsFetcher may implement it
+type StarHistoryBackfiller interface {
+	// BackfillStarHistory returns the star curve of a repo oldest first with at most maxRequests requests, sampled
+	// tells whether the curve was reconstructed from a part of the stargazers
+	BackfillStarHistory(ctx context.Context, repoName string, maxRequests int) (points []StarPoint, sampled bool, err error)
+}
+
+// blank assignment to verify that GitHubFetcher implements StarHistoryBackfiller
+var _ StarHistoryBackfiller = &GitHubFetcher{}
+
+// BackfillStarHistory reconstructs the star curve of a repo named like "owner/repo" from the starred_at timestamps of
+// its stargazers, the nth stargazer makes the star number n. Repos with more pages than maxRequests are sampled
+// evenly, and the newest stargazers beyond the ones the REST API serves are read with GraphQL when the fetcher is
+// authenticated. The curve keeps the last point of each day.
+func (f *GitHubFetcher) BackfillStarHistory(ctx context.Context, repoName string, maxRequests int) ([]StarPoint, bool, error) {
+	owner, repo, err := splitRepoName(repoName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	opt := &github.ListOptions{PerPage: stargazersPerPage}
+	first, resp, err := f.client.Activity.ListStargazers(ctx, owner, repo, opt)
+	if err != nil {
+		return nil, false, err
+	}
+	points := stargazerPagePoints(first, 1)
+	if resp.LastPage == 0 {
+		return dailyPoints(points), false, nil
+	}
+
+	lastPage, sampled := resp.LastPage, false
+	if lastPage > maxStargazerPages {
+		lastPage, sampled = maxStargazerPages, true
+	}
+	pages := samplePages(lastPage, maxRequests-1)
+	if len(pages) < lastPage-1 {
+		sampled = true
+	}
+	for _, page := range pages {
+		opt.Page = page
+		got, _, err := f.client.Activity.ListStargazers(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, false, err
+		}
+		points = append(points, stargazerPagePoints(got, page)...)
+	}
+
+	if resp.LastPage > maxStargazerPages && f.authenticated {
+		newest, err := f.newestStargazerPoints(ctx, owner, repo)
+		if err != nil {
+			log.Error(err, "read newest stargazers with GraphQL failed! ", "Repo", repoName)
+		} else {
+			points = append(points, newest...)
+		}
+	}
+	return dailyPoints(points), sampled, nil
+}
+
+// stargazerPagePoints turns each stargazer of a page into the star number it made
+func stargazerPagePoints(stargazers []*github.Stargazer, page int) []StarPoint {
+	points := make([]StarPoint, 0, len(stargazers))
+	for i, s := range stargazers {
+		points = append(points, StarPoint{
+			Time:  s.GetStarredAt().Time,
+			Stars: int64((page-1)*stargazersPerPage + i + 1),
+		})
+	}
+	return points
+}
+
+// samplePages returns n pages spread evenly over 2..lastPage ending with lastPage, all of them when n is large enough
+func samplePages(lastPage, n int) []int {
+	if n <= 0 || lastPage < 2 {
+		return nil
+	}
+	var pages []int
+	if n >= lastPage-1 {
+		for page := 2; page <= lastPage; page++ {
+			pages = append(pages, page)
+		}
+		return pages
+	}
+	for k := 1; k <= n; k++ {
+		page := 1 + (k*(lastPage-1)+n-1)/n
+		if len(pages) == 0 || pages[len(pages)-1] != page {
+			pages = append(pages, page)
+		}
+	}
+	return pages
+}
+
+// newestStargazerPoints reads the newest 100 stargazers with GraphQL, which unlike the REST API serves all of them
+func (f *GitHubFetcher) newestStargazerPoints(ctx context.Context, owner, repo string) ([]StarPoint, error) {
+	body := map[string]interface{}{
+		"query":     newestStargazersQuery,
+		"variables": map[string]string{"owner": owner, "name": repo},
+	}
+	req, err := f.client.NewRequest("POST", f.graphQLURL(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Repository *struct {
+				Stargazers struct {
+					TotalCount int64 `json:"totalCount"`
+					Edges      []struct {
+						StarredAt time.Time `json:"starredAt"`
+					} `json:"edges"`
+				} `json:"stargazers"`
+			} `json:"repository"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := f.client.Do(ctx, req, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Errors) > 0 {
+		return nil, errors.New(result.Errors[0].Message)
+	}
+	if result.Data.Repository == nil {
+		return nil, fmt.Errorf("repo : '%s/%s' not found , please check! ", owner, repo)
+	}
+
+	stargazers := result.Data.Repository.Stargazers
+	points := make([]StarPoint, 0, len(stargazers.Edges))
+	for i, edge := range stargazers.Edges {
+		points = append(points, StarPoint{
+			Time:  edge.StarredAt,
+			Stars: stargazers.TotalCount - int64(len(stargazers.Edges)-1-i),
+		})
+	}
+	return points, nil
+}
+
+// graphQLURL returns the GraphQL endpoint next to the REST API, /api/graphql for GitHub Enterprise
+func (f *GitHubFetcher) graphQLURL() string {
+	u := *f.client.BaseURL
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+	} else {
+		u.Path += "graphql"
+	}
+	return u.String()
+}
+
+// dailyPoints sorts points oldest first and keeps the last point of each day
+func dailyPoints(points []StarPoint) []StarPoint {
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+	var daily []StarPoint
+	for _, p := range points {
+		if n := len(daily); n > 0 && sameDay(daily[n-1].Time, p.Time) {
+			daily[n-1] = p
+			continue
+		}
+		daily = append(daily, p)
+	}
+	return daily
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.UTC().Truncate(24 * time.Hour).Equal(b.UTC().Truncate(24 * time.Hour))
+}
+
+// starHistory is the star curve of a repo kept in the companion ConfigMap, oldest first
+type starHistory struct {
+	// BackfilledAt is the unix time the curve was backfilled, 0 until then
+	BackfilledAt int64              `json:"backfilledAt,omitempty"`
+	Sampled      bool               `json:"sampled,omitempty"`
+	Points       []starHistoryPoint `json:"points"`
+}
+
+type starHistoryPoint struct {
+	Time  int64 `json:"time"`
+	Stars int64 `json:"stars"`
+	// Backfilled marks the points reconstructed from the stargazers, the others are live samples
+	Backfilled bool `json:"backfilled,omitempty"`
+}
+
+// backfill puts the backfilled points before the live samples recorded so far
+func (h *starHistory) backfill(points []StarPoint, sampled bool, now time.Time) {
+	firstLive := now.Unix()
+	if len(h.Points) > 0 {
+		firstLive = h.Points[0].Time
+	}
+	var merged []starHistoryPoint
+	for _, p := range points {
+		if p.Time.Unix() < firstLive {
+			merged = append(merged, starHistoryPoint{Time: p.Time.Unix(), Stars: p.Stars, Backfilled: true})
+		}
+	}
+	h.Points = append(merged, h.Points...)
+	h.BackfilledAt = now.Unix()
+	h.Sampled = sampled
+}
+
+// addLive records a live sample, it replaces the live sample of the same day
+func (h *starHistory) addLive(now time.Time, stars int64) {
+	point := starHistoryPoint{Time: now.Unix(), Stars: stars}
+	if n := len(h.Points); n > 0 && !h.Points[n-1].Backfilled && sameDay(time.Unix(h.Points[n-1].Time, 0), now) {
+		h.Points[n-1] = point
+		return
+	}
+	h.Points = append(h.Points, point)
+}
+
+func (h *starHistory) backfilledPoints() int64 {
+	var n int64
+	for _, p := range h.Points {
+		if p.Backfilled {
+			n++
+		}
+	}
+	return n
+}
+
+// refreshHistory backfills the star curve of the repos of gitStar not backfilled yet and adds the live samples of
+// this fetch to it in the companion ConfigMap, a failure is reported in status.history
+func (r *Runner) refreshHistory(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) {
+	if !gitStar.Spec.BackfillHistory {
+		gitStar.Status.History = nil
+		return
+	}
+
+	status := gitStar.Status.History.DeepCopy()
+	if status == nil {
+		status = &appV1.GitStarHistoryStatus{}
+	}
+	if err := r.recordHistory(ctx, gitStar, fetcher, status); err != nil {
+		log.Error(err, "record star history failed! ", "Request.Namespace", gitStar.Namespace, "Request.Name", gitStar.Name)
+		status.FailedReason = err.Error()
+	}
+	gitStar.Status.History = status
+}
+
+func (r *Runner) recordHistory(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher, status *appV1.GitStarHistoryStatus) error {
+	maxRequests := DefaultBackfillRequestLimit
+	if gitStar.Spec.BackfillRequestLimit > 0 {
+		maxRequests = int(gitStar.Spec.BackfillRequestLimit)
+	}
+	backfiller, canBackfill := fetcher.(StarHistoryBackfiller)
+
+	cm, err := resource.GetCompanion(ctx, r.Client, gitStar, HistoryCompanion)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var points, backfilledPoints int64
+	var sampled bool
+	var failures []string
+	data := map[string]string{}
+	for _, repoName := range gitStar.Spec.RepoNames() {
+		history := starHistory{}
+		if raw, ok := cm.Data[repoKey(repoName)]; ok {
+			if err := json.Unmarshal([]byte(raw), &history); err != nil {
+				log.Error(err, "parse star history failed! ", "Repo", repoName)
+				history = starHistory{}
+			}
+		}
+
+		// a failed backfill is retried by the next fetch
+		if history.BackfilledAt == 0 {
+			if !canBackfill {
+				failures = append(failures, "the fetcher can't backfill the star history")
+			} else if backfilled, s, err := backfiller.BackfillStarHistory(ctx, repoName, maxRequests); err != nil {
+				failures = append(failures, fmt.Sprintf("backfill '%s' failed: %v", repoName, err))
+			} else {
+				history.backfill(backfilled, s, now)
+			}
+		}
+		if stars, ok := liveStars(gitStar, repoName); ok {
+			history.addLive(now, stars)
+		}
+
+		raw, err := json.Marshal(history)
+		if err != nil {
+			return err
+		}
+		data[repoKey(repoName)] = string(raw)
+		points += int64(len(history.Points))
+		backfilledPoints += history.backfilledPoints()
+		sampled = sampled || history.Sampled
+	}
+
+	if !r.DryRun {
+		cm.Data = data
+		if err := resource.SaveCompanion(ctx, r.Client, cm); err != nil {
+			return fmt.Errorf("save star history failed: %v", err)
+		}
+	}
+
+	*status = appV1.GitStarHistoryStatus{
+		HistoryName:      cm.Name,
+		Points:           points,
+		BackfilledPoints: backfilledPoints,
+		Sampled:          sampled,
+		UpdatedAt:        metav1.NewTime(now),
+		FailedReason:     strings.Join(failures, "; "),
+	}
+	return nil
+}
+
+// liveStars returns the star number of repoName fetched by this refresh, false when its fetch failed
+func liveStars(gitStar *appV1.GitStar, repoName string) (int64, bool) {
+	if len(gitStar.Status.Repositories) == 0 {
+		return gitStar.Status.StarNumber, repoName == gitStar.Spec.RepoName
+	}
+	for _, repository := range gitStar.Status.Repositories {
+		if repository.RepoName == repoName {
+			return repository.StarNumber, repository.FailedReason == ""
+		}
+	}
+	return 0, false
+}
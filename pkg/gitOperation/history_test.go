@@ -0,0 +1,142 @@
+package gitOperation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/fakegithub"
+)
+
+func TestSamplePages(t *testing.T) {
+	tests := []struct {
+		lastPage int
+		n        int
+		want     []int
+	}{
+		{lastPage: 1, n: 5, want: nil},
+		{lastPage: 4, n: 5, want: []int{2, 3, 4}},
+		{lastPage: 10, n: 3, want: []int{4, 7, 10}},
+		{lastPage: 400, n: 1, want: []int{400}},
+		{lastPage: 10, n: 0, want: nil},
+	}
+	for _, tt := range tests {
+		if got := samplePages(tt.lastPage, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("samplePages(%d, %d) = %v, want %v", tt.lastPage, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestBackfillStarHistory(t *testing.T) {
+	tests := []struct {
+		name         string
+		stargazers   int
+		maxRequests  int
+		token        string
+		wantSampled  bool
+		wantLast     int64
+		wantRequests int
+		wantGraphQL  bool
+	}{
+		{name: "all pages", stargazers: 250, maxRequests: 50, wantLast: 250, wantRequests: 3},
+		{name: "sampled pages", stargazers: 250, maxRequests: 2, wantSampled: true, wantLast: 250, wantRequests: 2},
+		{name: "beyond the REST API anonymously", stargazers: 40050, maxRequests: 3, wantSampled: true, wantLast: 40000, wantRequests: 3},
+		{name: "beyond the REST API with GraphQL", stargazers: 40050, maxRequests: 3, token: testToken, wantSampled: true, wantLast: 40050, wantRequests: 4, wantGraphQL: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakegithub.NewServer()
+			defer server.Close()
+			server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stargazers: newTestStargazers(tt.stargazers)})
+
+			f, err := NewGitHubFetcherForURL(tt.token, server.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+			points, sampled, err := f.BackfillStarHistory(context.TODO(), "kuri-su/kblog", tt.maxRequests)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sampled != tt.wantSampled || points[len(points)-1].Stars != tt.wantLast {
+				t.Errorf("sampled = %v, last = %d, want %v, %d", sampled, points[len(points)-1].Stars, tt.wantSampled, tt.wantLast)
+			}
+			for i := 1; i < len(points); i++ {
+				if !points[i-1].Time.Before(points[i].Time) || sameDay(points[i-1].Time, points[i].Time) {
+					t.Fatalf("points %v and %v aren't on increasing days", points[i-1], points[i])
+				}
+			}
+
+			requests := server.Requests()
+			if len(requests) != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", len(requests), tt.wantRequests)
+			}
+			if graphQL := requests[len(requests)-1].Method == http.MethodPost; graphQL != tt.wantGraphQL {
+				t.Errorf("GraphQL = %v, want %v", graphQL, tt.wantGraphQL)
+			}
+		})
+	}
+}
+
+func TestRunnerBackfillHistory(t *testing.T) {
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 250, Stargazers: newTestStargazers(250)})
+	factory, err := GitHubFetcherFactoryForURL(server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitStar := newTestGitStar("default", "kblog", "kuri-su/kblog")
+	gitStar.Spec.BackfillHistory = true
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), gitStar)
+	runner := &Runner{Client: c, NewFetcher: factory}
+
+	for i := 0; i < 2; i++ {
+		if err := runner.Run("default", "kblog"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := &appV1.GitStar{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog"}, got); err != nil {
+		t.Fatal(err)
+	}
+	// 250 hours of stargazers make 11 days, and one live sample of today
+	status := got.Status.History
+	if status == nil || status.HistoryName != "kblog-history" || status.BackfilledPoints != 11 || status.Points != 12 || status.FailedReason != "" {
+		t.Fatalf("history = %+v, want 11 backfilled points and a live sample in kblog-history", status)
+	}
+
+	// the second fetch doesn't backfill again
+	stargazerRequests := 0
+	for _, req := range server.Requests() {
+		if strings.HasSuffix(req.URL.Path, "/stargazers") {
+			stargazerRequests++
+		}
+	}
+	if stargazerRequests != 3 {
+		t.Errorf("stargazer requests = %d, want 3", stargazerRequests)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog-history"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	history := starHistory{}
+	if err := json.Unmarshal([]byte(cm.Data["kuri-su_kblog.json"]), &history); err != nil {
+		t.Fatal(err)
+	}
+	last := history.Points[len(history.Points)-1]
+	if history.BackfilledAt == 0 || !history.Points[0].Backfilled || last.Backfilled || last.Stars != 250 {
+		t.Errorf("history = %+v, want backfilled points then a live sample", history)
+	}
+}
@@ -0,0 +1,157 @@
+package gitOperation
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
+)
+
+// FetchLimits bounds the requests of all GitHub clients of the process
+type FetchLimits struct {
+	// Concurrency is the maximum number of requests in flight, 0 means unlimited
+	Concurrency int
+	// QPS is the rate of requests to each host, refilling a token bucket of Burst requests, 0 means unlimited
+	QPS   float64
+	Burst int
+	// Timeout bounds each request including the wait for the limits, 0 means no timeout
+	Timeout time.Duration
+}
+
+// DefaultFetchLimits returns the limits used until SetFetchLimits is called
+func DefaultFetchLimits() FetchLimits {
+	return FetchLimits{
+		Concurrency: 4,
+		QPS:         1,
+		Burst:       10,
+		Timeout:     30 * time.Second,
+	}
+}
+
+// AddFlags adds the flags of the limits to flags, the current values are the defaults
+func (l *FetchLimits) AddFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&l.Concurrency, "fetch-concurrency", l.Concurrency,
+		"maximum number of requests to GitHub in flight, 0 means unlimited")
+	flags.Float64Var(&l.QPS, "fetch-qps", l.QPS,
+		"requests per second to each GitHub host, 0 means unlimited")
+	flags.IntVar(&l.Burst, "fetch-burst", l.Burst,
+		"requests to each GitHub host allowed at once above --fetch-qps")
+	flags.DurationVar(&l.Timeout, "fetch-timeout", l.Timeout,
+		"timeout of each request to GitHub including the wait for the limits, 0 means no timeout")
+}
+
+var (
+	fetchLimitsMu  sync.RWMutex
+	fetchTransport = newLimitedTransport(http.DefaultTransport, DefaultFetchLimits())
+	fetchTimeout   = DefaultFetchLimits().Timeout
+)
+
+// SetFetchLimits applies limits to the GitHub clients created afterwards, they share the concurrency and rate limits
+func SetFetchLimits(limits FetchLimits) {
+	fetchLimitsMu.Lock()
+	defer fetchLimitsMu.Unlock()
+	fetchTransport = newLimitedTransport(http.DefaultTransport, limits)
+	fetchTimeout = limits.Timeout
+}
+
+// newHTTPClient creates the HTTP client of a GitHub client, authenticated with gitHubOAuthToken unless it is empty
+func newHTTPClient(gitHubOAuthToken string) *http.Client {
+	fetchLimitsMu.RLock()
+	base := &http.Client{Transport: fetchTransport, Timeout: fetchTimeout}
+	fetchLimitsMu.RUnlock()
+
+	if gitHubOAuthToken == "" {
+		return base
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
+	c := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: gitHubOAuthToken}))
+	c.Timeout = base.Timeout
+	return c
+}
+
+// limitedTransport limits the requests in flight and the rate of requests to each host
+type limitedTransport struct {
+	next http.RoundTripper
+	// slots holds a token per request in flight, nil means unlimited
+	slots chan struct{}
+	qps   rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func newLimitedTransport(next http.RoundTripper, limits FetchLimits) *limitedTransport {
+	t := &limitedTransport{
+		next:     next,
+		qps:      rate.Limit(limits.QPS),
+		burst:    limits.Burst,
+		limiters: map[string]*rate.Limiter{},
+	}
+	if limits.Concurrency > 0 {
+		t.slots = make(chan struct{}, limits.Concurrency)
+	}
+	if t.burst < 1 {
+		t.burst = 1
+	}
+	return t
+}
+
+// RoundTrip waits for the rate limit of the host and a free slot, the slot is released when the body is closed
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if limiter := t.limiter(req.URL.Host); limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if t.slots == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	select {
+	case t.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-t.slots }
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+func (t *limitedTransport) limiter(host string) *rate.Limiter {
+	if t.qps <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limiter, ok := t.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(t.qps, t.burst)
+		t.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// releasingBody releases the slot of its request once when it is closed
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,101 @@
+package gitOperation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimitedTransport(t *testing.T) {
+	tests := []struct {
+		name            string
+		limits          FetchLimits
+		requests        int
+		wantMaxInFlight int
+		wantMinDuration time.Duration
+	}{
+		{
+			name:            "unlimited",
+			requests:        4,
+			wantMaxInFlight: 4,
+		},
+		{
+			name:            "concurrency",
+			limits:          FetchLimits{Concurrency: 2},
+			requests:        6,
+			wantMaxInFlight: 2,
+		},
+		{
+			name:            "qps after burst",
+			limits:          FetchLimits{QPS: 20, Burst: 2},
+			requests:        4,
+			wantMaxInFlight: 4,
+			wantMinDuration: 90 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			inFlight, maxInFlight := 0, 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mu.Lock()
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				mu.Unlock()
+				time.Sleep(50 * time.Millisecond)
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}))
+			defer server.Close()
+
+			c := &http.Client{Transport: newLimitedTransport(http.DefaultTransport, tt.limits)}
+			start := time.Now()
+			var wg sync.WaitGroup
+			for i := 0; i < tt.requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := c.Get(server.URL)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					_, _ = ioutil.ReadAll(resp.Body)
+					resp.Body.Close()
+				}()
+			}
+			wg.Wait()
+
+			if maxInFlight > tt.wantMaxInFlight {
+				t.Errorf("max requests in flight = %d, want at most %d", maxInFlight, tt.wantMaxInFlight)
+			}
+			if d := time.Since(start); d < tt.wantMinDuration {
+				t.Errorf("requests took %v, want at least %v", d, tt.wantMinDuration)
+			}
+		})
+	}
+}
+
+func TestSetFetchLimitsTimeout(t *testing.T) {
+	defer SetFetchLimits(DefaultFetchLimits())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	SetFetchLimits(FetchLimits{Timeout: 50 * time.Millisecond})
+	if _, err := newHTTPClient("").Get(server.URL); err == nil {
+		t.Error("request without token didn't time out")
+	}
+	if _, err := newHTTPClient(testToken).Get(server.URL); err == nil {
+		t.Error("request with token didn't time out")
+	}
+}
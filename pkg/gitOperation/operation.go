@@ -6,16 +6,18 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/github"
 	"github.com/operator-framework/operator-sdk/pkg/log/zap"
-	"golang.org/x/oauth2"
 	v1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -25,128 +27,340 @@ import (
 	"gitstar-operator/pkg/apis"
 	appV1 "gitstar-operator/pkg/apis/app/v1"
 	customV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/operatorconfig"
 	"gitstar-operator/pkg/resource"
 )
 
+// the default location of the GitHub OAuth token, the GitStarOperatorConfig can move it
 const (
-	GitHubOAuthTokenCMName      = "gitstar-github-token"
-	GitHubOAuthTokenCMNameSpace = "default"
-	GitHubOAuthTokenCMFileName  = "token"
+	GitHubOAuthTokenCMName      = operatorconfig.DefaultCredentialsName
+	GitHubOAuthTokenCMNameSpace = operatorconfig.DefaultCredentialsNamespace
+	GitHubOAuthTokenCMFileName  = operatorconfig.DefaultCredentialsKey
 )
 
 var (
-	log              = logf.NewDelegatingLogger(zap.Logger())
-	gitHubOAuthToken = ""
-	k8sClient        = newK8SClient()
+	log = logf.NewDelegatingLogger(zap.Logger())
+
+	k8sClient     client.Client
+	k8sClientOnce sync.Once
 )
 
+// Run refreshes the status of a GitStar using the in-cluster kubernetes client, the name and namespace
+// fall back to the env of the queryJob when empty
 func Run(gitStarNameSpace, gitStarName string) {
 	log.Info("start")
 
-	err := InitEnv(&gitStarNameSpace, &gitStarName, &gitHubOAuthToken, k8sClient)
-	if err != nil {
+	c := defaultK8SClient()
+	if c == nil {
+		err := errors.New("k8sClient is nil")
 		log.Error(err, "")
 		return
 	}
 
-	if k8sClient == nil {
-		err := errors.New("k8sClient is nil")
+	if err := NewRunner(c).Run(gitStarNameSpace, gitStarName); err != nil {
 		log.Error(err, "")
-		return
+	}
+}
+
+const (
+	// DefaultCollectorTimeout is the time each optional collector of a refresh, like the stargazers, is allowed
+	DefaultCollectorTimeout = 2 * time.Minute
+
+	// statusUpdateTimeout bounds the status update of a refresh
+	statusUpdateTimeout = 30 * time.Second
+	// maxStatusUpdateReserve bounds the part of the time of a refresh the collectors leave to the status update
+	maxStatusUpdateReserve = 5 * time.Second
+)
+
+// Runner refreshes the status of GitStars
+type Runner struct {
+	Client client.Client
+	// NewFetcher creates the fetcher of the repo numbers, DefaultFetcherFactory when nil
+	NewFetcher FetcherFactory
+	// DryRun only logs the fetched numbers without updating the GitStar status
+	DryRun bool
+	// CollectorTimeout bounds each optional collector of a refresh on its own, DefaultCollectorTimeout when 0
+	CollectorTimeout time.Duration
+}
+
+// NewRunner creates a Runner fetching the repo numbers from the public GitHub API
+func NewRunner(c client.Client) *Runner {
+	return &Runner{Client: c, NewFetcher: DefaultFetcherFactory}
+}
+
+// Run refreshes the status of one GitStar, the name and namespace fall back to the env of the queryJob when empty
+func (r *Runner) Run(gitStarNameSpace, gitStarName string) error {
+	return r.RunContext(context.TODO(), gitStarNameSpace, gitStarName)
+}
+
+// RunContext is Run with a context bounding the kubernetes and GitHub requests
+func (r *Runner) RunContext(ctx context.Context, gitStarNameSpace, gitStarName string) error {
+	var gitHubOAuthToken string
+	err := InitEnv(&gitStarNameSpace, &gitStarName, &gitHubOAuthToken, r.Client)
+	if err != nil {
+		return err
 	}
 
-	// start
 	reqLogger := log.WithValues("Request.Namespace", gitStarNameSpace, "Request.Name", gitStarName)
 
 	gitStar := &appV1.GitStar{}
-	err = k8sClient.Get(context.TODO(), types.NamespacedName{
+	err = r.Client.Get(ctx, types.NamespacedName{
 		Namespace: gitStarNameSpace,
 		Name:      gitStarName,
 	}, gitStar)
 	if err != nil {
 		reqLogger.Error(err, "get gitStar apiObject failed! ")
-		return
+		return err
 	}
 
-	starNumber, err := GetStarOfRepo(gitStar, gitHubOAuthToken)
+	return r.RefreshContext(ctx, gitStar, r.fetcher(gitHubOAuthToken))
+}
+
+// RunAll refreshes the status of every GitStar in namespace (all namespaces when empty) matching selector,
+// it keeps going when a GitStar fails and returns an error counting the failures
+func (r *Runner) RunAll(namespace string, selector labels.Selector) error {
+	gitStars := &appV1.GitStarList{}
+	err := r.Client.List(context.TODO(), gitStars,
+		client.InNamespace(namespace),
+		client.MatchingLabelsSelector{Selector: selector})
 	if err != nil {
-		reqLogger.Error(err, "get star number of repo failed! ")
+		log.Error(err, "list gitStar apiObjects failed! ")
+		return err
+	}
+
+	// the token may differ by namespace
+	fetchers := map[string]RepoStatsFetcher{}
+	failed := 0
+	for i := range gitStars.Items {
+		gitStar := &gitStars.Items[i]
+		fetcher, ok := fetchers[gitStar.Namespace]
+		if !ok {
+			fetcher = r.fetcher(GetGitHubOAuthTokenForNamespace(r.Client, gitStar.Namespace))
+			fetchers[gitStar.Namespace] = fetcher
+		}
+		if err := r.Refresh(gitStar, fetcher); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("refresh %d of %d gitStars failed", failed, len(gitStars.Items))
+	}
+	log.Info(fmt.Sprintf("refresh %d gitStars success", len(gitStars.Items)))
+	return nil
+}
+
+// Refresh fetches the numbers of the repo of gitStar and updates its status, a failed fetch is
+// recorded in status.failedReason and keeps the previous numbers
+func (r *Runner) Refresh(gitStar *appV1.GitStar, fetcher RepoStatsFetcher) error {
+	return r.RefreshContext(context.TODO(), gitStar, fetcher)
+}
+
+// RefreshContext is Refresh with a context bounding the whole refresh. The optional collectors, like the stargazers,
+// each have a budget of their own within it and leave a part of its time to the status update, so a slow collector
+// doesn't lose the numbers.
+func (r *Runner) RefreshContext(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) error {
+	reqLogger := log.WithValues("Request.Namespace", gitStar.Namespace, "Request.Name", gitStar.Name)
+
+	var fetchErr error
+	if len(gitStar.Spec.Repositories) == 0 {
+		var stats *RepoStats
+		stats, fetchErr = fetcher.FetchRepoStats(ctx, gitStar.Spec.RepoName)
+		if fetchErr != nil {
+			reqLogger.Error(fetchErr, "get star number of repo failed! ")
+		} else {
+			gitStar.Status.StarNumber = stats.StarNumber
+			gitStar.Status.ForkNumber = stats.ForkNumber
+		}
+		gitStar.Status.Repositories = nil
+	} else {
+		fetchErr = fetchRepositories(ctx, gitStar, fetcher)
+	}
+	if fetchErr != nil {
 		if gitStar.Status.UpdatedAt.IsZero() {
 			gitStar.Status.UpdatedAt = metav1.NewTime(time.Unix(0, 0))
 		}
-		gitStar.Status = customV1.GitStarStatus{
-			StarNumber:   gitStar.Status.StarNumber,
-			UpdatedAt:    gitStar.Status.UpdatedAt,
-			FailedReason: err.Error(),
-		}
+		gitStar.Status.FailedReason = fetchErr.Error()
 	} else {
-		gitStar.Status = customV1.GitStarStatus{
-			StarNumber:   starNumber,
-			UpdatedAt:    metav1.NewTime(time.Now()),
-			FailedReason: "",
+		gitStar.Status.UpdatedAt = metav1.NewTime(time.Now())
+		gitStar.Status.FailedReason = ""
+		collectCtx, cancel := collectorsContext(ctx)
+		for _, collect := range []collector{
+			r.refreshStargazers,
+			r.refreshHistory,
+			r.refreshReleases,
+			r.refreshActivity,
+			r.refreshContributors,
+			r.refreshTraffic,
+		} {
+			r.runCollector(collectCtx, collect, gitStar, fetcher)
 		}
+		cancel()
+	}
+
+	if r.DryRun {
+		reqLogger.Info(fmt.Sprintf("dry run, skip update repo '%s', star number: '%d'", gitStar.Spec.RepoName, gitStar.Status.StarNumber))
+		return fetchErr
 	}
 
-	err = UpdateGitStarObj(k8sClient, gitStar)
+	updateCtx, cancel := context.WithTimeout(ctx, statusUpdateTimeout)
+	defer cancel()
+	err := r.Client.Status().Update(updateCtx, gitStar)
 	if err != nil {
-		log.Error(err, "update gitstar obj failed! ")
-		return
+		reqLogger.Error(err, "update gitstar obj failed! ")
+		return err
 	}
 	reqLogger.Info(fmt.Sprintf("update repo '%s', star number: '%d'", gitStar.Spec.RepoName, gitStar.Status.StarNumber))
 	reqLogger.Info("update gitStar success \n")
+	return fetchErr
+}
+
+// collector refreshes an optional part of the status of gitStar, like its stargazers, a failure is reported in that
+// part of the status
+type collector func(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher)
 
+// collectorsContext bounds the collectors of a refresh by ctx, leaving a quarter of its time, at most
+// maxStatusUpdateReserve, to the status update
+func collectorsContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	reserve := time.Until(deadline) / 4
+	if reserve > maxStatusUpdateReserve {
+		reserve = maxStatusUpdateReserve
+	}
+	return context.WithDeadline(ctx, deadline.Add(-reserve))
 }
 
-func newK8SClient() client.Client {
-	cfg, err := rest.InClusterConfig()
+// runCollector runs collect with a budget of its own within ctx
+func (r *Runner) runCollector(ctx context.Context, collect collector, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) {
+	timeout := r.CollectorTimeout
+	if timeout <= 0 {
+		timeout = DefaultCollectorTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	collect(ctx, gitStar, fetcher)
+}
+
+// fetchRepositories fetches each repo of a GitStar with spec.repositories into status.repositories and sums them
+// up, a failed repo keeps its previous numbers and only fails the GitStar when all repos failed
+func fetchRepositories(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) error {
+	reqLogger := log.WithValues("Request.Namespace", gitStar.Namespace, "Request.Name", gitStar.Name)
+
+	previous := make(map[string]appV1.GitStarRepositoryStatus, len(gitStar.Status.Repositories))
+	for _, status := range gitStar.Status.Repositories {
+		previous[status.RepoName] = status
+	}
+
+	now := metav1.NewTime(time.Now())
+	repoNames := gitStar.Spec.RepoNames()
+	statuses := make([]appV1.GitStarRepositoryStatus, 0, len(repoNames))
+	var starNumber, forkNumber int64
+	var failures []string
+	for _, repoName := range repoNames {
+		status := previous[repoName]
+		status.RepoName = repoName
+		stats, err := fetcher.FetchRepoStats(ctx, repoName)
+		if err != nil {
+			reqLogger.Error(err, "get star number of repo failed! ", "Repo", repoName)
+			status.FailedReason = err.Error()
+			failures = append(failures, fmt.Sprintf("%s: %v", repoName, err))
+		} else {
+			status.StarNumber = stats.StarNumber
+			status.ForkNumber = stats.ForkNumber
+			status.UpdatedAt = now
+			status.FailedReason = ""
+		}
+		starNumber += status.StarNumber
+		forkNumber += status.ForkNumber
+		statuses = append(statuses, status)
+	}
+
+	gitStar.Status.Repositories = statuses
+	if len(failures) == len(statuses) {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	gitStar.Status.StarNumber = starNumber
+	gitStar.Status.ForkNumber = forkNumber
+	if len(failures) > 0 {
+		reqLogger.Info(fmt.Sprintf("fetch %d of %d repos failed", len(failures), len(statuses)))
+	}
+	return nil
+}
+
+func (r *Runner) fetcher(gitHubOAuthToken string) RepoStatsFetcher {
+	if r.NewFetcher == nil {
+		return DefaultFetcherFactory(gitHubOAuthToken)
+	}
+	return r.NewFetcher(gitHubOAuthToken)
+}
+
+func defaultK8SClient() client.Client {
+	k8sClientOnce.Do(func() {
+		c, err := NewK8SClient("")
+		if err != nil {
+			log.Error(err, "new kubernetes client was failed! ")
+			return
+		}
+		k8sClient = c
+	})
+	return k8sClient
+}
+
+// NewK8SClient creates a kubernetes client from the kubeconfig file, when kubeconfig is empty the in cluster
+// config is used and it falls back to $KUBECONFIG or ~/.kube/config when not running in a cluster
+func NewK8SClient(kubeconfig string) (client.Client, error) {
+	cfg, err := newK8SConfig(kubeconfig)
 	if err != nil {
-		log.Error(err, "get kubernetes config in cluster was failed! ")
-		return nil
+		return nil, err
 	}
 	mapper, err := apiutil.NewDiscoveryRESTMapper(cfg)
 	if err != nil {
 		log.Error(err, "get mapper was failed! ")
-		return nil
+		return nil, err
 	}
 	scheme := scheme.Scheme
-	apis.AddToScheme(scheme)
+	if err := apis.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
 	c, err := client.New(cfg, client.Options{
 		Scheme: scheme,
 		Mapper: mapper,
 	})
 	if err != nil {
 		log.Error(err, "new kubernetes client for config was failed ! ")
-		return nil
+		return nil, err
 	}
-	return c
+	return c, nil
 }
 
-func GetStarOfRepo(gitStar *customV1.GitStar, gitHubOAuthToken string) (int64, error) {
-	split := strings.Split(gitStar.Spec.RepoName, "/")
-	if len(split) != 2 {
-		return -1, errors.New("The repo name is invalid, please check! ")
+func newK8SConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig == "" {
+		cfg, err := rest.InClusterConfig()
+		if err == nil {
+			return cfg, nil
+		}
+		log.Info("get kubernetes config in cluster was failed, fall back to kubeconfig", "error", err.Error())
 	}
 
-	var c *github.Client
-	if gitHubOAuthToken != "" {
-		c = github.NewClient(oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: gitHubOAuthToken},
-		)))
-		log.Info("get githubOAuthToken," + gitHubOAuthToken)
-	} else {
-		c = github.NewClient(nil)
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		log.Error(err, "get kubernetes config from kubeconfig was failed! ")
+		return nil, err
 	}
+	return cfg, nil
+}
 
-	get, _, err := c.Repositories.Get(context.TODO(), split[0], split[1])
+// GetStarOfRepo fetches the star number of the repo of gitStar
+func GetStarOfRepo(fetcher RepoStatsFetcher, gitStar *customV1.GitStar) (int64, error) {
+	stats, err := fetcher.FetchRepoStats(context.TODO(), gitStar.Spec.RepoName)
 	if err != nil {
 		return -1, err
-	} else if get == nil {
-		return -1, errors.New(fmt.Sprintf("repo : '%s' not found , please check! ", gitStar.Spec.RepoName))
-	} else if get.StargazersCount == nil {
-		return -1, errors.New(fmt.Sprintf("repo : '%s' star number is nil , please check! ", gitStar.Spec.RepoName))
 	}
-
-	return int64(*get.StargazersCount), nil
+	return stats.StarNumber, nil
 }
 
 func UpdateGitStarObj(c client.Client, gitStar *customV1.GitStar) error {
@@ -172,25 +386,61 @@ func InitEnv(gitStarNameSpace, gitStarName, gitHubOAuthToken *string, c client.C
 		*gitStarName = name
 	}
 
-	// init github oauth token
+	// init github oauth token, a CronJob passes the credentials resolved by the operator since the service account of
+	// the queryJob may not be allowed to read the GitStarOperatorConfig
+	if credentials, ok := credentialsFromEnv(); ok {
+		*gitHubOAuthToken = readGitHubOAuthToken(c, credentials)
+	} else {
+		*gitHubOAuthToken = GetGitHubOAuthTokenForNamespace(c, *gitStarNameSpace)
+	}
+
+	return nil
+}
+
+// credentialsFromEnv returns the token ConfigMap set in the env of the queryJob by its CronJob
+func credentialsFromEnv() (appV1.CredentialsReference, bool) {
+	credentials := appV1.CredentialsReference{
+		Namespace: os.Getenv(resource.ENVGitStarCredentialsNamespace),
+		Name:      os.Getenv(resource.ENVGitStarCredentialsName),
+		Key:       os.Getenv(resource.ENVGitStarCredentialsKey),
+	}
+	return credentials, credentials.Namespace != "" && credentials.Name != "" && credentials.Key != ""
+}
+
+// GetGitHubOAuthToken reads the GitHub OAuth token from the token ConfigMap, returns "" if it is not configured
+func GetGitHubOAuthToken(c client.Client) string {
+	return GetGitHubOAuthTokenForNamespace(c, "")
+}
+
+// GetGitHubOAuthTokenForNamespace reads the GitHub OAuth token of the GitStars of namespace from the ConfigMap
+// configured by the GitStarOperatorConfig, returns "" if it is not configured
+func GetGitHubOAuthTokenForNamespace(c client.Reader, namespace string) string {
+	return readGitHubOAuthToken(c, operatorconfig.SettingsFor(context.TODO(), c, namespace).Credentials)
+}
+
+// readGitHubOAuthToken reads the GitHub OAuth token from the ConfigMap of credentials, returns "" if it is not there
+func readGitHubOAuthToken(c client.Reader, credentials appV1.CredentialsReference) string {
 	oAuthCM := &v1.ConfigMap{}
 	err := c.Get(context.TODO(), types.NamespacedName{
-		Namespace: GitHubOAuthTokenCMNameSpace,
-		Name:      GitHubOAuthTokenCMName,
+		Namespace: credentials.Namespace,
+		Name:      credentials.Name,
 	}, oAuthCM)
 	if err != nil && k8serrors.IsNotFound(err) {
 		log.Info("not found oauth token cm !")
-		return nil
+		return ""
 	} else if err != nil {
 		log.Error(err, "get oauth token cm failed !")
-		return nil
+		return ""
 	}
 
-	if data, ok := oAuthCM.Data[GitHubOAuthTokenCMFileName]; ok && len(strings.TrimSpace(data)) == 40 {
-		*gitHubOAuthToken = strings.TrimSpace(data)
-	} else {
-		return nil
+	if data, ok := oAuthCM.Data[credentials.Key]; ok && len(strings.TrimSpace(data)) == 40 {
+		return strings.TrimSpace(data)
 	}
 
-	return nil
+	return ""
+}
+
+// ListReposOfOwner lists all repositories of a GitHub organization, falling back to a user when no organization is found
+func ListReposOfOwner(ctx context.Context, owner, gitHubOAuthToken string) ([]*github.Repository, error) {
+	return NewGitHubFetcher(gitHubOAuthToken).ListRepos(ctx, owner)
 }
@@ -0,0 +1,512 @@
+package gitOperation
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"gitstar-operator/pkg/apis"
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/fakegithub"
+	"gitstar-operator/pkg/resource"
+)
+
+const testToken = "0123456789012345678901234567890123456789"
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newTestFetcher(t *testing.T, server *fakegithub.Server) RepoStatsFetcher {
+	f, err := NewGitHubFetcherForURL("", server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func newTestGitStar(namespace, name, repoName string) *appV1.GitStar {
+	return &appV1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       appV1.GitStarSpec{RepoName: repoName},
+	}
+}
+
+func newTokenConfigMap(token string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: GitHubOAuthTokenCMNameSpace, Name: GitHubOAuthTokenCMName},
+		Data:       map[string]string{GitHubOAuthTokenCMFileName: token},
+	}
+}
+
+func TestGetStarOfRepo(t *testing.T) {
+	tests := []struct {
+		name      string
+		repoName  string
+		setup     func(s *fakegithub.Server)
+		want      int64
+		wantError string
+	}{
+		{
+			name:     "found",
+			repoName: "kuri-su/kblog",
+			want:     42,
+		},
+		{
+			name:      "invalid repo name",
+			repoName:  "kblog",
+			want:      -1,
+			wantError: "repo name is invalid",
+		},
+		{
+			name:      "empty owner",
+			repoName:  "/kblog",
+			want:      -1,
+			wantError: "repo name is invalid",
+		},
+		{
+			name:      "not found",
+			repoName:  "kuri-su/missing",
+			want:      -1,
+			wantError: "404",
+		},
+		{
+			name:     "server error",
+			repoName: "kuri-su/kblog",
+			setup: func(s *fakegithub.Server) {
+				s.SetError("/repos/kuri-su/kblog", http.StatusInternalServerError)
+			},
+			want:      -1,
+			wantError: "500",
+		},
+		{
+			name:     "rate limited",
+			repoName: "kuri-su/kblog",
+			setup: func(s *fakegithub.Server) {
+				s.SetRateLimit(0)
+			},
+			want:      -1,
+			wantError: "rate limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakegithub.NewServer()
+			defer server.Close()
+			server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42})
+			if tt.setup != nil {
+				tt.setup(server)
+			}
+
+			got, err := GetStarOfRepo(newTestFetcher(t, server), newTestGitStar("default", "kblog", tt.repoName))
+			if tt.wantError == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantError != "" && (err == nil || !strings.Contains(err.Error(), tt.wantError)) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantError)
+			}
+			if got != tt.want {
+				t.Errorf("stars = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitEnv(t *testing.T) {
+	tests := []struct {
+		name          string
+		namespace     string
+		gitStarName   string
+		env           map[string]string
+		objs          []runtime.Object
+		wantNamespace string
+		wantName      string
+		wantToken     string
+		wantError     bool
+	}{
+		{
+			name:          "arguments win over env",
+			namespace:     "ns",
+			gitStarName:   "kblog",
+			env:           map[string]string{resource.ENVGitStarNameSpace: "env-ns", resource.ENVGitStarName: "env-name"},
+			wantNamespace: "ns",
+			wantName:      "kblog",
+		},
+		{
+			name:          "fall back to env",
+			env:           map[string]string{resource.ENVGitStarNameSpace: "env-ns", resource.ENVGitStarName: "env-name"},
+			wantNamespace: "env-ns",
+			wantName:      "env-name",
+		},
+		{
+			name:        "namespace missing",
+			gitStarName: "kblog",
+			wantName:    "kblog",
+			wantError:   true,
+		},
+		{
+			name:          "name missing",
+			namespace:     "ns",
+			wantNamespace: "ns",
+			wantError:     true,
+		},
+		{
+			name:          "token configured",
+			namespace:     "ns",
+			gitStarName:   "kblog",
+			objs:          []runtime.Object{newTokenConfigMap(testToken + "\n")},
+			wantNamespace: "ns",
+			wantName:      "kblog",
+			wantToken:     testToken,
+		},
+		{
+			name:        "credentials of the CronJob",
+			namespace:   "ns",
+			gitStarName: "kblog",
+			env: map[string]string{
+				resource.ENVGitStarCredentialsNamespace: "ns",
+				resource.ENVGitStarCredentialsName:      "team-token",
+				resource.ENVGitStarCredentialsKey:       "github",
+			},
+			objs: []runtime.Object{newTokenConfigMap("default token"), &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "team-token"},
+				Data:       map[string]string{"github": testToken},
+			}},
+			wantNamespace: "ns",
+			wantName:      "kblog",
+			wantToken:     testToken,
+		},
+		{
+			name:          "token placeholder ignored",
+			namespace:     "ns",
+			gitStarName:   "kblog",
+			objs:          []runtime.Object{newTokenConfigMap("<input your 'GitHub Personal access tokens' in here>")},
+			wantNamespace: "ns",
+			wantName:      "kblog",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{resource.ENVGitStarNameSpace, resource.ENVGitStarName, resource.ENVGitStarCredentialsNamespace,
+				resource.ENVGitStarCredentialsName, resource.ENVGitStarCredentialsKey} {
+				old, ok := os.LookupEnv(key)
+				if ok {
+					defer os.Setenv(key, old)
+				} else {
+					defer os.Unsetenv(key)
+				}
+				if value, ok := tt.env[key]; ok {
+					os.Setenv(key, value)
+				} else {
+					os.Unsetenv(key)
+				}
+			}
+
+			c := fake.NewFakeClientWithScheme(newTestScheme(t), tt.objs...)
+			namespace, name, token := tt.namespace, tt.gitStarName, ""
+			err := InitEnv(&namespace, &name, &token, c)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("error = %v, want error %v", err, tt.wantError)
+			}
+			if namespace != tt.wantNamespace || name != tt.wantName || token != tt.wantToken {
+				t.Errorf("got (%q, %q, %q), want (%q, %q, %q)",
+					namespace, name, token, tt.wantNamespace, tt.wantName, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestRunnerRun(t *testing.T) {
+	tests := []struct {
+		name             string
+		gitStar          *appV1.GitStar
+		setup            func(s *fakegithub.Server)
+		dryRun           bool
+		wantError        bool
+		wantStars        int64
+		wantForks        int64
+		wantFailedReason bool
+		wantUpdated      bool
+	}{
+		{
+			name:        "update status",
+			gitStar:     newTestGitStar("default", "kblog", "kuri-su/kblog"),
+			wantStars:   42,
+			wantForks:   7,
+			wantUpdated: true,
+		},
+		{
+			name: "update keeps the last refresh request",
+			gitStar: func() *appV1.GitStar {
+				g := newTestGitStar("default", "kblog", "kuri-su/kblog")
+				g.Status.LastRefreshRequest = "2020-04-20T12:00:00Z"
+				return g
+			}(),
+			wantStars:   42,
+			wantForks:   7,
+			wantUpdated: true,
+		},
+		{
+			name: "failed fetch keeps the previous numbers",
+			gitStar: func() *appV1.GitStar {
+				g := newTestGitStar("default", "kblog", "kuri-su/kblog")
+				g.Status.StarNumber = 40
+				g.Status.ForkNumber = 6
+				return g
+			}(),
+			setup: func(s *fakegithub.Server) {
+				s.SetError("/repos/kuri-su/kblog", http.StatusBadGateway)
+			},
+			wantError:        true,
+			wantStars:        40,
+			wantForks:        6,
+			wantFailedReason: true,
+		},
+		{
+			name:             "repo not found",
+			gitStar:          newTestGitStar("default", "missing", "kuri-su/missing"),
+			wantError:        true,
+			wantFailedReason: true,
+		},
+		{
+			name:      "dry run doesn't update",
+			gitStar:   newTestGitStar("default", "kblog", "kuri-su/kblog"),
+			dryRun:    true,
+			wantStars: 0,
+		},
+		{
+			name:      "gitStar not found",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakegithub.NewServer()
+			defer server.Close()
+			server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42, Forks: 7})
+			if tt.setup != nil {
+				tt.setup(server)
+			}
+			factory, err := GitHubFetcherFactoryForURL(server.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var objs []runtime.Object
+			name := "kblog"
+			if tt.gitStar != nil {
+				objs = append(objs, tt.gitStar)
+				name = tt.gitStar.Name
+			}
+			c := fake.NewFakeClientWithScheme(newTestScheme(t), objs...)
+			runner := &Runner{Client: c, NewFetcher: factory, DryRun: tt.dryRun}
+
+			err = runner.Run("default", name)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("error = %v, want error %v", err, tt.wantError)
+			}
+			if tt.gitStar == nil {
+				return
+			}
+
+			got := &appV1.GitStar{}
+			if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Status.StarNumber != tt.wantStars || got.Status.ForkNumber != tt.wantForks {
+				t.Errorf("numbers = (%d, %d), want (%d, %d)",
+					got.Status.StarNumber, got.Status.ForkNumber, tt.wantStars, tt.wantForks)
+			}
+			if (got.Status.FailedReason != "") != tt.wantFailedReason {
+				t.Errorf("failedReason = %q, want failed %v", got.Status.FailedReason, tt.wantFailedReason)
+			}
+			if got.Status.LastRefreshRequest != tt.gitStar.Status.LastRefreshRequest {
+				t.Errorf("lastRefreshRequest = %q, want %q", got.Status.LastRefreshRequest, tt.gitStar.Status.LastRefreshRequest)
+			}
+			// a failed first fetch records the epoch as updatedAt
+			if (got.Status.UpdatedAt.Unix() > 0) != tt.wantUpdated {
+				t.Errorf("updatedAt = %v, want updated %v", got.Status.UpdatedAt, tt.wantUpdated)
+			}
+		})
+	}
+}
+
+func TestRunnerRunRepositories(t *testing.T) {
+	tests := []struct {
+		name             string
+		repositories     []string
+		previous         []appV1.GitStarRepositoryStatus
+		wantError        bool
+		wantStars        int64
+		wantForks        int64
+		wantFailedReason bool
+		wantRepos        map[string]int64
+		wantRepoFailed   map[string]bool
+	}{
+		{
+			name:         "sum up repos",
+			repositories: []string{"kuri-su/kblog-ui", "kuri-su/kblog"},
+			wantStars:    50,
+			wantForks:    9,
+			wantRepos:    map[string]int64{"kuri-su/kblog": 42, "kuri-su/kblog-ui": 8},
+		},
+		{
+			name:         "failed repo keeps its previous numbers",
+			repositories: []string{"kuri-su/kblog-ui", "kuri-su/missing"},
+			previous: []appV1.GitStarRepositoryStatus{
+				{RepoName: "kuri-su/missing", StarNumber: 3},
+			},
+			wantStars:      53,
+			wantForks:      9,
+			wantRepos:      map[string]int64{"kuri-su/kblog": 42, "kuri-su/kblog-ui": 8, "kuri-su/missing": 3},
+			wantRepoFailed: map[string]bool{"kuri-su/missing": true},
+		},
+		{
+			name:         "all repos failed",
+			repositories: []string{"kuri-su/missing"},
+			previous: []appV1.GitStarRepositoryStatus{
+				{RepoName: "kuri-su/missing", StarNumber: 3},
+			},
+			wantError:        true,
+			wantFailedReason: true,
+			wantRepos:        map[string]int64{"kuri-su/gone": 0, "kuri-su/missing": 3},
+			wantRepoFailed:   map[string]bool{"kuri-su/gone": true, "kuri-su/missing": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakegithub.NewServer()
+			defer server.Close()
+			server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42, Forks: 7})
+			server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog-ui", Stars: 8, Forks: 2})
+			factory, err := GitHubFetcherFactoryForURL(server.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gitStar := newTestGitStar("default", "kblog", "kuri-su/kblog")
+			if tt.wantError {
+				gitStar.Spec.RepoName = "kuri-su/gone"
+			}
+			gitStar.Spec.Repositories = tt.repositories
+			gitStar.Status.Repositories = tt.previous
+			c := fake.NewFakeClientWithScheme(newTestScheme(t), gitStar)
+			runner := &Runner{Client: c, NewFetcher: factory}
+
+			err = runner.Run("default", "kblog")
+			if (err != nil) != tt.wantError {
+				t.Fatalf("error = %v, want error %v", err, tt.wantError)
+			}
+
+			got := &appV1.GitStar{}
+			if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog"}, got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Status.StarNumber != tt.wantStars || got.Status.ForkNumber != tt.wantForks {
+				t.Errorf("numbers = (%d, %d), want (%d, %d)",
+					got.Status.StarNumber, got.Status.ForkNumber, tt.wantStars, tt.wantForks)
+			}
+			if (got.Status.FailedReason != "") != tt.wantFailedReason {
+				t.Errorf("failedReason = %q, want failed %v", got.Status.FailedReason, tt.wantFailedReason)
+			}
+			if len(got.Status.Repositories) != len(tt.wantRepos) {
+				t.Fatalf("repositories = %+v, want %v", got.Status.Repositories, tt.wantRepos)
+			}
+			for _, repo := range got.Status.Repositories {
+				stars, ok := tt.wantRepos[repo.RepoName]
+				if !ok || repo.StarNumber != stars {
+					t.Errorf("repo %s stars = %d, want %d", repo.RepoName, repo.StarNumber, stars)
+				}
+				if (repo.FailedReason != "") != tt.wantRepoFailed[repo.RepoName] {
+					t.Errorf("repo %s failedReason = %q, want failed %v",
+						repo.RepoName, repo.FailedReason, tt.wantRepoFailed[repo.RepoName])
+				}
+			}
+		})
+	}
+}
+
+func TestRunnerRunAll(t *testing.T) {
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42})
+	server.AddRepo(fakegithub.Repo{Owner: "kubernetes", Name: "kubernetes", Stars: 70000})
+	factory, err := GitHubFetcherFactoryForURL(server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	selected := newTestGitStar("default", "kblog", "kuri-su/kblog")
+	selected.Labels = map[string]string{"team": "blog"}
+	other := newTestGitStar("default", "kubernetes", "kubernetes/kubernetes")
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), selected, other)
+	runner := &Runner{Client: c, NewFetcher: factory}
+
+	if err := runner.RunAll("", labels.SelectorFromSet(labels.Set{"team": "blog"})); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int64{"kblog": 42, "kubernetes": 0}
+	for name, stars := range want {
+		got := &appV1.GitStar{}
+		if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: name}, got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Status.StarNumber != stars {
+			t.Errorf("%s stars = %d, want %d", name, got.Status.StarNumber, stars)
+		}
+	}
+}
+
+func TestListReposOfOwner(t *testing.T) {
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddOrg("kuricat")
+	for _, name := range []string{"a", "b", "c"} {
+		server.AddRepo(fakegithub.Repo{Owner: "kuricat", Name: name})
+	}
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog"})
+
+	f, err := NewGitHubFetcherForURL("", server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := f.ListRepos(context.TODO(), "kuricat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 3 {
+		t.Errorf("org repos = %d, want 3", len(repos))
+	}
+
+	repos, err = f.ListRepos(context.TODO(), "kuri-su")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].GetFullName() != "kuri-su/kblog" {
+		t.Errorf("user repos = %v, want [kuri-su/kblog]", repos)
+	}
+}
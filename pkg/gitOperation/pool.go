@@ -0,0 +1,93 @@
+package gitOperation
+
+import (
+	"context"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// DefaultRefreshWorkers is the default number of GitStars a RefreshPool refreshes at the same time
+	DefaultRefreshWorkers = 4
+	// DefaultRefreshTimeout is the default time a RefreshPool allows for the refresh of one GitStar
+	DefaultRefreshTimeout = 30 * time.Second
+
+	// maxRefreshRetries bounds the retries of a refresh whose status update conflicted
+	maxRefreshRetries = 3
+)
+
+// blank assignment to verify that RefreshPool implements manager.Runnable
+var _ manager.Runnable = &RefreshPool{}
+
+// RefreshPool refreshes GitStars in the background with a bounded number of workers, so the reconcilers
+// never wait for GitHub. A GitStar enqueued again before its refresh starts is refreshed once.
+type RefreshPool struct {
+	runner  *Runner
+	workers int
+	timeout time.Duration
+	queue   workqueue.RateLimitingInterface
+}
+
+// NewRefreshPool creates a RefreshPool refreshing with runner, it starts working when added to a manager
+func NewRefreshPool(runner *Runner, workers int, timeout time.Duration) *RefreshPool {
+	if workers <= 0 {
+		workers = DefaultRefreshWorkers
+	}
+	if timeout <= 0 {
+		timeout = DefaultRefreshTimeout
+	}
+	return &RefreshPool{
+		runner:  runner,
+		workers: workers,
+		timeout: timeout,
+		queue:   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "gitstar-refresh"),
+	}
+}
+
+// Enqueue schedules the refresh of a GitStar without blocking
+func (p *RefreshPool) Enqueue(namespace, name string) {
+	p.queue.Add(types.NamespacedName{Namespace: namespace, Name: name})
+}
+
+// Start runs the workers until stop is closed, it implements manager.Runnable. Closing stop also cancels the
+// refreshes in progress.
+func (p *RefreshPool) Start(stop <-chan struct{}) error {
+	log.Info("Starting the refresh pool", "workers", p.workers, "timeout", p.timeout.String())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < p.workers; i++ {
+		go func() {
+			for p.processNext(ctx) {
+			}
+		}()
+	}
+
+	<-stop
+	p.queue.ShutDown()
+	return nil
+}
+
+func (p *RefreshPool) processNext(ctx context.Context) bool {
+	item, shutdown := p.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer p.queue.Done(item)
+	key := item.(types.NamespacedName)
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	err := p.runner.RunContext(ctx, key.Namespace, key.Name)
+
+	// a failed fetch is recorded in the status and retried by the CronJob, only a conflicting update is retried here
+	if k8serrors.IsConflict(err) && p.queue.NumRequeues(item) < maxRefreshRetries {
+		p.queue.AddRateLimited(item)
+		return true
+	}
+	p.queue.Forget(item)
+	return true
+}
@@ -0,0 +1,184 @@
+package gitOperation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+// blockingFetcher serves the stars of "kuri-su/slow" only when the context is done and counts the concurrent fetches
+type blockingFetcher struct {
+	mu            sync.Mutex
+	running       int
+	maxConcurrent int
+}
+
+func (f *blockingFetcher) FetchRepoStats(ctx context.Context, repoName string) (*RepoStats, error) {
+	f.mu.Lock()
+	f.running++
+	if f.running > f.maxConcurrent {
+		f.maxConcurrent = f.running
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.running--
+		f.mu.Unlock()
+	}()
+
+	if repoName == "kuri-su/slow" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	time.Sleep(20 * time.Millisecond)
+	return &RepoStats{StarNumber: 42}, nil
+}
+
+func TestRefreshPool(t *testing.T) {
+	objs := []runtime.Object{newTestGitStar("default", "slow", "kuri-su/slow")}
+	for i := 0; i < 6; i++ {
+		objs = append(objs, newTestGitStar("default", fmt.Sprintf("fast-%d", i), "kuri-su/kblog"))
+	}
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), objs...)
+	fetcher := &blockingFetcher{}
+	runner := &Runner{Client: c, NewFetcher: func(string) RepoStatsFetcher { return fetcher }}
+	pool := NewRefreshPool(runner, 2, 200*time.Millisecond)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		_ = pool.Start(stop)
+	}()
+
+	pool.Enqueue("default", "slow")
+	for i := 0; i < 6; i++ {
+		pool.Enqueue("default", fmt.Sprintf("fast-%d", i))
+		pool.Enqueue("default", fmt.Sprintf("fast-%d", i))
+	}
+
+	waitStatus := func(name string, cond func(*appV1.GitStar) bool) *appV1.GitStar {
+		gitStar := &appV1.GitStar{}
+		err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, gitStar)
+			return err == nil && cond(gitStar), err
+		})
+		if err != nil {
+			t.Fatalf("wait GitStar %s: %v, last status %+v", name, err, gitStar.Status)
+		}
+		return gitStar
+	}
+
+	// the slow fetch doesn't stall the others and times out
+	for i := 0; i < 6; i++ {
+		waitStatus(fmt.Sprintf("fast-%d", i), func(g *appV1.GitStar) bool { return g.Status.StarNumber == 42 })
+	}
+	slow := waitStatus("slow", func(g *appV1.GitStar) bool { return g.Status.FailedReason != "" })
+	if !strings.Contains(slow.Status.FailedReason, context.DeadlineExceeded.Error()) {
+		t.Errorf("failedReason = %q, want a timeout", slow.Status.FailedReason)
+	}
+
+	fetcher.mu.Lock()
+	defer fetcher.mu.Unlock()
+	if fetcher.maxConcurrent > 2 {
+		t.Errorf("max concurrent fetches = %d, want at most 2", fetcher.maxConcurrent)
+	}
+}
+
+// slowCollectorsFetcher serves the stars at once, its releases and activity only when the context is done
+type slowCollectorsFetcher struct{}
+
+func (slowCollectorsFetcher) FetchRepoStats(ctx context.Context, repoName string) (*RepoStats, error) {
+	return &RepoStats{StarNumber: 42}, nil
+}
+
+func (slowCollectorsFetcher) FetchReleaseStats(ctx context.Context, repoName string) (*ReleaseStats, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (slowCollectorsFetcher) FetchActivity(ctx context.Context, repoNames []string, since time.Time) (*Activity, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// deadlineClient fails the status updates with a done context like the apiserver client does
+type deadlineClient struct {
+	client.Client
+}
+
+func (c deadlineClient) Status() client.StatusWriter {
+	return deadlineStatusWriter{c.Client.Status()}
+}
+
+type deadlineStatusWriter struct {
+	client.StatusWriter
+}
+
+func (w deadlineStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func TestRefreshSlowCollectors(t *testing.T) {
+	gitStar := newTestGitStar("default", "kblog", "kuri-su/kblog")
+	gitStar.Spec.TrackReleases = true
+	gitStar.Spec.TrackActivity = true
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), gitStar)
+	runner := &Runner{Client: deadlineClient{c}, CollectorTimeout: 50 * time.Millisecond}
+
+	// the collectors together outlast the context of the refresh
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Millisecond)
+	defer cancel()
+	if err := runner.RefreshContext(ctx, gitStar.DeepCopy(), slowCollectorsFetcher{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &appV1.GitStar{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.StarNumber != 42 || got.Status.FailedReason != "" {
+		t.Errorf("status = %+v, want 42 stars", got.Status)
+	}
+	for name, failedReason := range map[string]string{
+		"latestRelease": got.Status.LatestRelease.FailedReason,
+		"activity":      got.Status.Activity.FailedReason,
+	} {
+		if !strings.Contains(failedReason, "deadline") {
+			t.Errorf("%s.failedReason = %q, want the deadline of the collector", name, failedReason)
+		}
+	}
+}
+
+func TestRefreshCancelStopsCollectors(t *testing.T) {
+	gitStar := newTestGitStar("default", "kblog", "kuri-su/kblog")
+	gitStar.Spec.TrackReleases = true
+	gitStar.Spec.TrackActivity = true
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), gitStar)
+	runner := &Runner{Client: deadlineClient{c}, CollectorTimeout: time.Minute}
+
+	// cancelling the context of the refresh, like a stopping manager does, stops the collectors and the update
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	err := runner.RefreshContext(ctx, gitStar.DeepCopy(), slowCollectorsFetcher{})
+	if err != context.Canceled {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("refresh took %v after the cancel, want the collectors stopped", elapsed)
+	}
+}
@@ -0,0 +1,168 @@
+package gitOperation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+const (
+	// EventReasonNewRelease is the reason of the Event recorded when a repo of a GitStar publishes a new release
+	EventReasonNewRelease = "NewRelease"
+
+	// maxReleasePages bounds the requests listing the releases of a repo
+	maxReleasePages = 10
+	releasesPerPage = 100
+)
+
+// Release is a published release of a repo
+type Release struct {
+	TagName     string
+	Name        string
+	URL         string
+	PublishedAt time.Time
+	// DownloadCount sums the downloads of the assets of the release
+	DownloadCount int64
+}
+
+// ReleaseStats are the releases and tags of a repo
+type ReleaseStats struct {
+	// Latest is the newest release, prereleases aside, nil when there is none
+	Latest        *Release
+	ReleaseCount  int64
+	DownloadCount int64
+	TagCount      int64
+}
+
+// ReleaseFetcher fetches the releases of a repo, a RepoStatsFetcher may implement it
+type ReleaseFetcher interface {
+	FetchReleaseStats(ctx context.Context, repoName string) (*ReleaseStats, error)
+}
+
+// blank assignment to verify that GitHubFetcher implements ReleaseFetcher
+var _ ReleaseFetcher = &GitHubFetcher{}
+
+// FetchReleaseStats fetches the releases and counts the tags of a repo named like "owner/repo", the releases beyond
+// the first 1000 are left out
+func (f *GitHubFetcher) FetchReleaseStats(ctx context.Context, repoName string) (*ReleaseStats, error) {
+	owner, repo, err := splitRepoName(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ReleaseStats{}
+	opt := &github.ListOptions{PerPage: releasesPerPage}
+	for page := 0; page < maxReleasePages; page++ {
+		releases, resp, err := f.client.Repositories.ListReleases(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range releases {
+			if r.GetDraft() {
+				continue
+			}
+			release := &Release{
+				TagName:     r.GetTagName(),
+				Name:        r.GetName(),
+				URL:         r.GetHTMLURL(),
+				PublishedAt: r.GetPublishedAt().Time,
+			}
+			for _, asset := range r.Assets {
+				release.DownloadCount += int64(asset.GetDownloadCount())
+			}
+			stats.ReleaseCount++
+			stats.DownloadCount += release.DownloadCount
+			if !r.GetPrerelease() && (stats.Latest == nil || release.PublishedAt.After(stats.Latest.PublishedAt)) {
+				stats.Latest = release
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	// with one tag per page the last page is the number of tags
+	tags, resp, err := f.client.Repositories.ListTags(ctx, owner, repo, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return nil, err
+	}
+	stats.TagCount = int64(len(tags))
+	if resp.LastPage > 0 {
+		stats.TagCount = int64(resp.LastPage)
+	}
+	return stats, nil
+}
+
+// refreshReleases reports the newest release of the repos of gitStar in status.latestRelease and records an Event
+// when it changed, a failure keeps the previous report
+func (r *Runner) refreshReleases(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) {
+	if !gitStar.Spec.TrackReleases {
+		gitStar.Status.LatestRelease = nil
+		return
+	}
+
+	previous := gitStar.Status.LatestRelease
+	status, err := fetchReleases(ctx, gitStar, fetcher)
+	if err != nil {
+		log.Error(err, "fetch releases failed! ", "Request.Namespace", gitStar.Namespace, "Request.Name", gitStar.Name)
+		status = previous.DeepCopy()
+		if status == nil {
+			status = &appV1.GitStarReleaseStatus{}
+		}
+		status.FailedReason = err.Error()
+		gitStar.Status.LatestRelease = status
+		return
+	}
+	gitStar.Status.LatestRelease = status
+
+	// the first release seen isn't new, neither is an older one that became the latest after the newest was deleted
+	if previous == nil || previous.TagName == "" || status.TagName == "" ||
+		(previous.RepoName == status.RepoName && previous.TagName == status.TagName) ||
+		!status.PublishedAt.After(previous.PublishedAt.Time) {
+		return
+	}
+	r.recordEvent(ctx, gitStar, v1.EventTypeNormal, EventReasonNewRelease,
+		fmt.Sprintf("%s published release %s", status.RepoName, status.TagName))
+}
+
+func fetchReleases(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) (*appV1.GitStarReleaseStatus, error) {
+	releaseFetcher, ok := fetcher.(ReleaseFetcher)
+	if !ok {
+		return nil, errors.New("the fetcher can't fetch releases")
+	}
+
+	status := &appV1.GitStarReleaseStatus{UpdatedAt: metav1.NewTime(time.Now())}
+	var failures []string
+	for _, repoName := range gitStar.Spec.RepoNames() {
+		stats, err := releaseFetcher.FetchReleaseStats(ctx, repoName)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", repoName, err))
+			continue
+		}
+		status.ReleaseCount += stats.ReleaseCount
+		status.DownloadCount += stats.DownloadCount
+		status.TagCount += stats.TagCount
+		if latest := stats.Latest; latest != nil && (status.TagName == "" || latest.PublishedAt.After(status.PublishedAt.Time)) {
+			status.RepoName = repoName
+			status.TagName = latest.TagName
+			status.Name = latest.Name
+			status.URL = latest.URL
+			status.PublishedAt = metav1.NewTime(latest.PublishedAt)
+			status.LatestDownloadCount = latest.DownloadCount
+		}
+	}
+	// the totals of some repos would look like a drop
+	if len(failures) > 0 {
+		return nil, errors.New(strings.Join(failures, "; "))
+	}
+	return status, nil
+}
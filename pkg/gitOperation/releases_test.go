@@ -0,0 +1,115 @@
+package gitOperation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/fakegithub"
+)
+
+var releasesStart = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestReleases() []fakegithub.Release {
+	return []fakegithub.Release{
+		{TagName: "v1.1.0-rc.1", PublishedAt: releasesStart.Add(48 * time.Hour), Prerelease: true, Downloads: []int{1}},
+		{TagName: "v1.0.0", Name: "First stable", PublishedAt: releasesStart.Add(24 * time.Hour), Downloads: []int{10, 5}},
+		{TagName: "v0.9.0", PublishedAt: releasesStart, Downloads: []int{3}},
+	}
+}
+
+func TestFetchReleaseStats(t *testing.T) {
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Releases: newTestReleases(), Tags: []string{"v1.1.0-rc.1", "v1.0.0", "v0.9.0", "v0.1.0"}})
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "empty"})
+
+	f := newTestFetcher(t, server).(*GitHubFetcher)
+	stats, err := f.FetchReleaseStats(context.TODO(), "kuri-su/kblog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Latest == nil || stats.Latest.TagName != "v1.0.0" || stats.Latest.Name != "First stable" || stats.Latest.DownloadCount != 15 {
+		t.Errorf("latest = %+v, want v1.0.0 with 15 downloads", stats.Latest)
+	}
+	if stats.ReleaseCount != 3 || stats.DownloadCount != 19 || stats.TagCount != 4 {
+		t.Errorf("stats = %+v, want 3 releases, 19 downloads and 4 tags", stats)
+	}
+
+	stats, err = f.FetchReleaseStats(context.TODO(), "kuri-su/empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Latest != nil || stats.ReleaseCount != 0 || stats.TagCount != 0 {
+		t.Errorf("stats = %+v, want no releases", stats)
+	}
+}
+
+func TestRunnerTrackReleases(t *testing.T) {
+	server := fakegithub.NewServer()
+	defer server.Close()
+	releases := newTestReleases()
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42, Releases: releases})
+	factory, err := GitHubFetcherFactoryForURL(server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitStar := newTestGitStar("default", "kblog", "kuri-su/kblog")
+	gitStar.Spec.TrackReleases = true
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), gitStar)
+	runner := &Runner{Client: c, NewFetcher: factory}
+	run := func() (*appV1.GitStarReleaseStatus, []v1.Event) {
+		if err := runner.Run("default", "kblog"); err != nil {
+			t.Fatal(err)
+		}
+		got := &appV1.GitStar{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog"}, got); err != nil {
+			t.Fatal(err)
+		}
+		events := &v1.EventList{}
+		if err := c.List(context.TODO(), events, client.InNamespace("default")); err != nil {
+			t.Fatal(err)
+		}
+		return got.Status.LatestRelease, events.Items
+	}
+
+	// the first release seen isn't new
+	status, events := run()
+	if status == nil || status.TagName != "v1.0.0" || status.RepoName != "kuri-su/kblog" || status.ReleaseCount != 3 || len(events) != 0 {
+		t.Fatalf("latestRelease = %+v, events = %v, want v1.0.0 without events", status, events)
+	}
+
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42, Releases: append([]fakegithub.Release{
+		{TagName: "v1.1.0", PublishedAt: releasesStart.Add(72 * time.Hour)},
+	}, releases...)})
+	status, events = run()
+	if status.TagName != "v1.1.0" || len(events) != 1 {
+		t.Fatalf("latestRelease = %+v, events = %v, want v1.1.0 with an event", status, events)
+	}
+	event := events[0]
+	if event.Reason != EventReasonNewRelease || event.InvolvedObject.Name != "kblog" || event.Message != "kuri-su/kblog published release v1.1.0" {
+		t.Errorf("event = %+v, want a NewRelease event of kblog", event)
+	}
+
+	// a failed fetch keeps the release and doesn't record events
+	server.SetError("/repos/kuri-su/kblog/releases", 502)
+	status, events = run()
+	if status.TagName != "v1.1.0" || status.FailedReason == "" || len(events) != 1 {
+		t.Errorf("latestRelease = %+v, events = %v, want v1.1.0 with the failure", status, events)
+	}
+
+	// deleting the newest release moves the latest back to an older one without an event
+	server.SetError("/repos/kuri-su/kblog/releases", 0)
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42, Releases: releases})
+	status, events = run()
+	if status.TagName != "v1.0.0" || status.FailedReason != "" || len(events) != 1 {
+		t.Errorf("latestRelease = %+v, events = %v, want v1.0.0 without a new event", status, events)
+	}
+}
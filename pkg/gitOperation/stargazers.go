@@ -0,0 +1,318 @@
+package gitOperation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/resource"
+)
+
+const (
+	// StargazersCompanion is the kind of the companion ConfigMap keeping the stargazer snapshots of a GitStar
+	StargazersCompanion = "stargazers"
+
+	// DefaultStargazerLimit caps the stargazers kept per repo unless spec.stargazerLimit is set
+	DefaultStargazerLimit = 5000
+
+	// maxStargazerPages is the last page of stargazers the REST API serves, the newer stargazers of larger repos
+	// can't be listed
+	maxStargazerPages = 400
+	stargazersPerPage = 100
+
+	// maxReportedStargazers caps the logins listed in status.stargazers.new and status.stargazers.lost
+	maxReportedStargazers = 20
+)
+
+// snapshotStargazerBudget caps the stargazers kept across the repos of a GitStar, so its companion ConfigMap stays
+// below the size limit of objects even with the longest logins
+var snapshotStargazerBudget = 10000
+
+// Stargazer is a user starring a repo
+type Stargazer struct {
+	Login     string
+	StarredAt time.Time
+}
+
+// StargazerLister lists the stargazers of a repo, a RepoStatsFetcher may implement it
+type StargazerLister interface {
+	// ListStargazers lists the newest limit stargazers of a repo oldest first, truncated tells whether older
+	// stargazers were left out
+	ListStargazers(ctx context.Context, repoName string, limit int) (stargazers []Stargazer, truncated bool, err error)
+}
+
+// blank assignment to verify that GitHubFetcher implements StargazerLister
+var _ StargazerLister = &GitHubFetcher{}
+
+// ListStargazers lists the newest limit stargazers of a repo named like "owner/repo", paging backwards from the
+// last page so large repos only cost limit / 100 requests
+func (f *GitHubFetcher) ListStargazers(ctx context.Context, repoName string, limit int) ([]Stargazer, bool, error) {
+	owner, repo, err := splitRepoName(repoName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	opt := &github.ListOptions{PerPage: stargazersPerPage}
+	first, resp, err := f.client.Activity.ListStargazers(ctx, owner, repo, opt)
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.LastPage == 0 {
+		stargazers, truncated := newestStargazers(toStargazers(first), limit)
+		return stargazers, truncated, nil
+	}
+
+	lastPage, truncated := resp.LastPage, false
+	if lastPage > maxStargazerPages {
+		lastPage, truncated = maxStargazerPages, true
+	}
+	// the pages newest first
+	var pages [][]*github.Stargazer
+	count, page := 0, lastPage
+	for ; page > 1 && count < limit; page-- {
+		opt.Page = page
+		got, _, err := f.client.Activity.ListStargazers(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, false, err
+		}
+		pages = append(pages, got)
+		count += len(got)
+	}
+	if count < limit {
+		pages = append(pages, first)
+	} else {
+		truncated = true
+	}
+
+	var stargazers []Stargazer
+	for i := len(pages) - 1; i >= 0; i-- {
+		stargazers = append(stargazers, toStargazers(pages[i])...)
+	}
+	stargazers, cut := newestStargazers(stargazers, limit)
+	return stargazers, truncated || cut, nil
+}
+
+func toStargazers(page []*github.Stargazer) []Stargazer {
+	stargazers := make([]Stargazer, 0, len(page))
+	for _, s := range page {
+		stargazers = append(stargazers, Stargazer{Login: s.GetUser().GetLogin(), StarredAt: s.GetStarredAt().Time})
+	}
+	return stargazers
+}
+
+// newestStargazers sorts stargazers oldest first and keeps the newest limit of them
+func newestStargazers(stargazers []Stargazer, limit int) ([]Stargazer, bool) {
+	sort.SliceStable(stargazers, func(i, j int) bool {
+		return stargazers[i].StarredAt.Before(stargazers[j].StarredAt)
+	})
+	if len(stargazers) <= limit {
+		return stargazers, false
+	}
+	return stargazers[len(stargazers)-limit:], true
+}
+
+// stargazerSnapshot is the stargazers of a repo kept in the companion ConfigMap, oldest first
+type stargazerSnapshot struct {
+	Truncated  bool                     `json:"truncated,omitempty"`
+	Stargazers []stargazerSnapshotEntry `json:"stargazers"`
+}
+
+type stargazerSnapshotEntry struct {
+	Login string `json:"login"`
+	// StarredAt is a unix timestamp, it keeps the snapshots of large repos small
+	StarredAt int64 `json:"starredAt"`
+}
+
+func newStargazerSnapshot(stargazers []Stargazer, truncated bool) stargazerSnapshot {
+	snapshot := stargazerSnapshot{Truncated: truncated, Stargazers: make([]stargazerSnapshotEntry, 0, len(stargazers))}
+	for _, s := range stargazers {
+		snapshot.Stargazers = append(snapshot.Stargazers, stargazerSnapshotEntry{Login: s.Login, StarredAt: s.StarredAt.Unix()})
+	}
+	return snapshot
+}
+
+// oldest returns the starredAt of the oldest stargazer of a truncated snapshot, 0 when it has all of them
+func (s *stargazerSnapshot) oldest() int64 {
+	if !s.Truncated || len(s.Stargazers) == 0 {
+		return 0
+	}
+	return s.Stargazers[0].StarredAt
+}
+
+// diffStargazers returns the logins starring in current but not in previous and the other way round, newest
+// first. A truncated snapshot only covers its newest stargazers, the older ones are neither gained nor lost.
+func diffStargazers(previous, current stargazerSnapshot) (gained, lost []string) {
+	previousLogins := make(map[string]bool, len(previous.Stargazers))
+	for _, s := range previous.Stargazers {
+		previousLogins[s.Login] = true
+	}
+	currentLogins := make(map[string]bool, len(current.Stargazers))
+	for _, s := range current.Stargazers {
+		currentLogins[s.Login] = true
+	}
+
+	for i := len(current.Stargazers) - 1; i >= 0; i-- {
+		s := current.Stargazers[i]
+		if !previousLogins[s.Login] && s.StarredAt >= previous.oldest() {
+			gained = append(gained, s.Login)
+		}
+	}
+	for i := len(previous.Stargazers) - 1; i >= 0; i-- {
+		s := previous.Stargazers[i]
+		if !currentLogins[s.Login] && s.StarredAt >= current.oldest() {
+			lost = append(lost, s.Login)
+		}
+	}
+	return gained, lost
+}
+
+// repoKey is the key of the data of a repo in a companion ConfigMap
+func repoKey(repoName string) string {
+	return strings.Replace(repoName, "/", "_", 1) + ".json"
+}
+
+// refreshStargazers snapshots the stargazers of the repos of gitStar into its companion ConfigMap and reports the
+// stargazers gained and lost since the previous snapshot in status.stargazers, a failure keeps the previous report
+func (r *Runner) refreshStargazers(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) {
+	if !gitStar.Spec.TrackStargazers {
+		gitStar.Status.Stargazers = nil
+		return
+	}
+
+	status := gitStar.Status.Stargazers.DeepCopy()
+	if status == nil {
+		status = &appV1.GitStarStargazersStatus{}
+	}
+	if err := r.snapshotStargazers(ctx, gitStar, fetcher, status); err != nil {
+		log.Error(err, "snapshot stargazers failed! ", "Request.Namespace", gitStar.Namespace, "Request.Name", gitStar.Name)
+		status.FailedReason = err.Error()
+	}
+	gitStar.Status.Stargazers = status
+}
+
+func (r *Runner) snapshotStargazers(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher, status *appV1.GitStarStargazersStatus) error {
+	lister, ok := fetcher.(StargazerLister)
+	if !ok {
+		return errors.New("the fetcher can't list stargazers")
+	}
+	limit := DefaultStargazerLimit
+	if gitStar.Spec.StargazerLimit > 0 {
+		limit = int(gitStar.Spec.StargazerLimit)
+	}
+
+	cm, err := resource.GetCompanion(ctx, r.Client, gitStar, StargazersCompanion)
+	if err != nil {
+		return err
+	}
+
+	repoNames := gitStar.Spec.RepoNames()
+	listed := make([][]Stargazer, len(repoNames))
+	cuts := make([]bool, len(repoNames))
+	counts := make([]int, len(repoNames))
+	for i, repoName := range repoNames {
+		listed[i], cuts[i], err = lister.ListStargazers(ctx, repoName, limit)
+		if err != nil {
+			return fmt.Errorf("list stargazers of '%s' failed: %v", repoName, err)
+		}
+		counts[i] = len(listed[i])
+	}
+
+	// the newest stargazers of the repos above their share of the budget are kept
+	shares := shareStargazers(counts, snapshotStargazerBudget)
+	var count int64
+	var truncated bool
+	var gained, lost []string
+	data := map[string]string{}
+	for i, repoName := range repoNames {
+		stargazers, cut := listed[i], cuts[i]
+		if shares[i] < len(stargazers) {
+			stargazers, cut = stargazers[len(stargazers)-shares[i]:], true
+		}
+		current := newStargazerSnapshot(stargazers, cut)
+		// the first snapshot of a repo gains nobody
+		if raw, ok := cm.Data[repoKey(repoName)]; ok {
+			previous := stargazerSnapshot{}
+			if err := json.Unmarshal([]byte(raw), &previous); err != nil {
+				log.Error(err, "parse stargazer snapshot failed! ", "Repo", repoName)
+			} else {
+				g, l := diffStargazers(previous, current)
+				gained, lost = append(gained, g...), append(lost, l...)
+			}
+		}
+		raw, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+		data[repoKey(repoName)] = string(raw)
+		count += int64(len(stargazers))
+		truncated = truncated || cut
+	}
+
+	if !r.DryRun {
+		cm.Data = data
+		if err := resource.SaveCompanion(ctx, r.Client, cm); err != nil {
+			return fmt.Errorf("save stargazer snapshot failed: %v", err)
+		}
+	}
+
+	// a user starring several repos of the GitStar counts once
+	gained, lost = uniqueLogins(gained), uniqueLogins(lost)
+	*status = appV1.GitStarStargazersStatus{
+		SnapshotName: cm.Name,
+		Count:        count,
+		Truncated:    truncated,
+		NewCount:     int64(len(gained)),
+		New:          firstLogins(gained),
+		LostCount:    int64(len(lost)),
+		Lost:         firstLogins(lost),
+		UpdatedAt:    metav1.NewTime(time.Now()),
+	}
+	return nil
+}
+
+// shareStargazers splits budget between repos with counts stargazers, a repo with fewer stargazers than an even
+// share leaves the rest to the others
+func shareStargazers(counts []int, budget int) []int {
+	shares := make([]int, len(counts))
+	order := make([]int, len(counts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] < counts[order[j]] })
+	for n, i := range order {
+		share := budget / (len(order) - n)
+		if counts[i] < share {
+			share = counts[i]
+		}
+		shares[i] = share
+		budget -= share
+	}
+	return shares
+}
+
+func uniqueLogins(logins []string) []string {
+	seen := make(map[string]bool, len(logins))
+	unique := logins[:0]
+	for _, login := range logins {
+		if !seen[login] {
+			seen[login] = true
+			unique = append(unique, login)
+		}
+	}
+	return unique
+}
+
+func firstLogins(logins []string) []string {
+	if len(logins) > maxReportedStargazers {
+		return logins[:maxReportedStargazers]
+	}
+	return logins
+}
@@ -0,0 +1,249 @@
+package gitOperation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/fakegithub"
+	"gitstar-operator/pkg/resource"
+)
+
+var stargazersStart = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestStargazers returns n stargazers named user-0 .. user-(n-1) starring one hour apart, oldest first
+func newTestStargazers(n int) []fakegithub.Stargazer {
+	stargazers := make([]fakegithub.Stargazer, n)
+	for i := range stargazers {
+		stargazers[i] = fakegithub.Stargazer{Login: fmt.Sprintf("user-%d", i), StarredAt: stargazersStart.Add(time.Duration(i) * time.Hour)}
+	}
+	return stargazers
+}
+
+func TestListStargazers(t *testing.T) {
+	tests := []struct {
+		name          string
+		stargazers    int
+		limit         int
+		wantFirst     string
+		wantCount     int
+		wantTruncated bool
+		wantRequests  int
+	}{
+		{name: "one page", stargazers: 42, limit: 100, wantFirst: "user-0", wantCount: 42, wantRequests: 1},
+		{name: "all pages", stargazers: 250, limit: 1000, wantFirst: "user-0", wantCount: 250, wantRequests: 3},
+		{name: "newest pages", stargazers: 250, limit: 120, wantFirst: "user-130", wantCount: 120, wantTruncated: true, wantRequests: 3},
+		{name: "last page only", stargazers: 250, limit: 50, wantFirst: "user-200", wantCount: 50, wantTruncated: true, wantRequests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakegithub.NewServer()
+			defer server.Close()
+			server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stargazers: newTestStargazers(tt.stargazers)})
+
+			f := newTestFetcher(t, server).(*GitHubFetcher)
+			stargazers, truncated, err := f.ListStargazers(context.TODO(), "kuri-su/kblog", tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stargazers) != tt.wantCount || stargazers[0].Login != tt.wantFirst || truncated != tt.wantTruncated {
+				t.Errorf("got %d stargazers from %s, truncated %v, want %d from %s, truncated %v",
+					len(stargazers), stargazers[0].Login, truncated, tt.wantCount, tt.wantFirst, tt.wantTruncated)
+			}
+			if !stargazers[0].StarredAt.Before(stargazers[len(stargazers)-1].StarredAt) {
+				t.Errorf("stargazers aren't oldest first")
+			}
+			if got := len(server.Requests()); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestDiffStargazers(t *testing.T) {
+	snapshot := func(truncated bool, logins ...string) stargazerSnapshot {
+		s := stargazerSnapshot{Truncated: truncated}
+		for _, login := range logins {
+			// user-N starred at N
+			var n int64
+			fmt.Sscanf(login, "user-%d", &n)
+			s.Stargazers = append(s.Stargazers, stargazerSnapshotEntry{Login: login, StarredAt: n})
+		}
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		previous   stargazerSnapshot
+		current    stargazerSnapshot
+		wantGained []string
+		wantLost   []string
+	}{
+		{
+			name:       "gained and lost",
+			previous:   snapshot(false, "user-1", "user-2", "user-3"),
+			current:    snapshot(false, "user-1", "user-3", "user-4", "user-5"),
+			wantGained: []string{"user-5", "user-4"},
+			wantLost:   []string{"user-2"},
+		},
+		{
+			name:     "unchanged",
+			previous: snapshot(false, "user-1", "user-2"),
+			current:  snapshot(false, "user-1", "user-2"),
+		},
+		{
+			name:       "stargazers out of the window of a truncated snapshot aren't lost",
+			previous:   snapshot(true, "user-3", "user-4", "user-5"),
+			current:    snapshot(true, "user-5", "user-6", "user-7"),
+			wantGained: []string{"user-7", "user-6"},
+		},
+		{
+			name:       "unstarred inside the window is lost",
+			previous:   snapshot(true, "user-3", "user-4", "user-5"),
+			current:    snapshot(true, "user-3", "user-5", "user-6"),
+			wantGained: []string{"user-6"},
+			wantLost:   []string{"user-4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gained, lost := diffStargazers(tt.previous, tt.current)
+			if !reflect.DeepEqual(gained, tt.wantGained) || !reflect.DeepEqual(lost, tt.wantLost) {
+				t.Errorf("diff = (%v, %v), want (%v, %v)", gained, lost, tt.wantGained, tt.wantLost)
+			}
+		})
+	}
+}
+
+func TestRunnerTrackStargazers(t *testing.T) {
+	server := fakegithub.NewServer()
+	defer server.Close()
+	stargazers := newTestStargazers(3)
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 3, Stargazers: stargazers})
+	factory, err := GitHubFetcherFactoryForURL(server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitStar := newTestGitStar("default", "kblog", "kuri-su/kblog")
+	gitStar.UID = "kblog-uid"
+	gitStar.Spec.TrackStargazers = true
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), gitStar)
+	runner := &Runner{Client: c, NewFetcher: factory}
+	get := func() *appV1.GitStar {
+		got := &appV1.GitStar{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog"}, got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	// the first snapshot gains nobody
+	if err := runner.Run("default", "kblog"); err != nil {
+		t.Fatal(err)
+	}
+	status := get().Status.Stargazers
+	if status == nil || status.Count != 3 || status.NewCount != 0 || status.SnapshotName != "kblog-stargazers" {
+		t.Fatalf("stargazers = %+v, want 3 stargazers in kblog-stargazers", status)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog-stargazers"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.OwnerReferences) != 1 || cm.OwnerReferences[0].UID != "kblog-uid" || cm.Labels[resource.CompanionLabel] != "kblog" {
+		t.Errorf("companion = %+v, want it owned by the GitStar", cm.ObjectMeta)
+	}
+	if !strings.Contains(cm.Data["kuri-su_kblog.json"], `"login":"user-2"`) {
+		t.Errorf("snapshot = %v, want user-2", cm.Data)
+	}
+
+	// user-1 unstarred, user-3 starred
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 3, Stargazers: []fakegithub.Stargazer{
+		stargazers[0], stargazers[2], {Login: "user-3", StarredAt: stargazersStart.Add(3 * time.Hour)},
+	}})
+	if err := runner.Run("default", "kblog"); err != nil {
+		t.Fatal(err)
+	}
+	status = get().Status.Stargazers
+	if status.NewCount != 1 || !reflect.DeepEqual(status.New, []string{"user-3"}) ||
+		status.LostCount != 1 || !reflect.DeepEqual(status.Lost, []string{"user-1"}) {
+		t.Errorf("stargazers = %+v, want user-3 new and user-1 lost", status)
+	}
+
+	// a failed listing keeps the previous report
+	server.SetError("/repos/kuri-su/kblog/stargazers", 502)
+	if err := runner.Run("default", "kblog"); err != nil {
+		t.Fatal(err)
+	}
+	status = get().Status.Stargazers
+	if status.FailedReason == "" || status.NewCount != 1 {
+		t.Errorf("stargazers = %+v, want the previous report with the failure", status)
+	}
+}
+
+func TestShareStargazers(t *testing.T) {
+	tests := []struct {
+		counts []int
+		budget int
+		want   []int
+	}{
+		{[]int{100, 200}, 1000, []int{100, 200}},
+		{[]int{5000, 5000, 5000}, 10000, []int{3333, 3333, 3334}},
+		{[]int{5000, 10, 5000}, 10000, []int{4995, 10, 4995}},
+		{[]int{0, 8}, 4, []int{0, 4}},
+		{nil, 10000, []int{}},
+	}
+	for _, tt := range tests {
+		if got := shareStargazers(tt.counts, tt.budget); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("shareStargazers(%v, %d) = %v, want %v", tt.counts, tt.budget, got, tt.want)
+		}
+	}
+}
+
+func TestRunnerStargazerBudget(t *testing.T) {
+	defer func(budget int) { snapshotStargazerBudget = budget }(snapshotStargazerBudget)
+	snapshotStargazerBudget = 4
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 5, Stargazers: newTestStargazers(5)})
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog-ui", Stars: 1, Stargazers: newTestStargazers(1)})
+	factory, err := GitHubFetcherFactoryForURL(server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitStar := newTestGitStar("default", "kblog", "kuri-su/kblog")
+	gitStar.Spec.Repositories = []string{"kuri-su/kblog-ui"}
+	gitStar.Spec.TrackStargazers = true
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), gitStar)
+	runner := &Runner{Client: c, NewFetcher: factory}
+	if err := runner.Run("default", "kblog"); err != nil {
+		t.Fatal(err)
+	}
+
+	// kblog-ui leaves 3 stargazers of the budget to kblog, its newest are kept
+	got := &appV1.GitStar{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if status := got.Status.Stargazers; status == nil || status.Count != 4 || !status.Truncated || status.FailedReason != "" {
+		t.Fatalf("stargazers = %+v, want 4 truncated stargazers", status)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog-stargazers"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot := cm.Data["kuri-su_kblog.json"]; strings.Contains(snapshot, `"user-1"`) || !strings.Contains(snapshot, `"user-2"`) {
+		t.Errorf("snapshot = %s, want user-2 to user-4", snapshot)
+	}
+}
@@ -0,0 +1,318 @@
+package gitOperation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/resource"
+)
+
+const (
+	// TrafficCompanion is the kind of the companion ConfigMap keeping the daily traffic of a GitStar
+	TrafficCompanion = "traffic"
+
+	// maxTrafficDays caps the days of traffic kept per repo, about two years
+	maxTrafficDays = 730
+	// maxReportedReferrers caps the referrers in the status, GitHub serves the top 10 of each repo
+	maxReportedReferrers = 10
+	trafficDayLayout     = "2006-01-02"
+)
+
+// ErrNoPushAccess is returned by a TrafficFetcher for a repo its token has no push access to
+var ErrNoPushAccess = errors.New("the token has no push access to the repo")
+
+// TrafficDay is the traffic of a repo on a day
+type TrafficDay struct {
+	// Day is the start of the day in UTC
+	Day            time.Time
+	Views          int64
+	UniqueVisitors int64
+	Clones         int64
+	UniqueCloners  int64
+}
+
+// Referrer is a site referring visitors to a repo
+type Referrer struct {
+	Referrer       string
+	Views          int64
+	UniqueVisitors int64
+}
+
+// Traffic is the traffic of a repo in the last 14 days, GitHub doesn't keep older traffic
+type Traffic struct {
+	Views          int64
+	UniqueVisitors int64
+	Clones         int64
+	UniqueCloners  int64
+	// Days are the daily views and clones, oldest first
+	Days []TrafficDay
+	// Referrers are the top referrers, most views first
+	Referrers []Referrer
+}
+
+// TrafficFetcher fetches the traffic of a repo, a RepoStatsFetcher may implement it
+type TrafficFetcher interface {
+	FetchTraffic(ctx context.Context, repoName string) (*Traffic, error)
+}
+
+// blank assignment to verify that GitHubFetcher implements TrafficFetcher
+var _ TrafficFetcher = &GitHubFetcher{}
+
+// FetchTraffic fetches the views, clones and top referrers of the last 14 days of a repo named like "owner/repo", it
+// returns ErrNoPushAccess when the token has no push access to the repo, GitHub only serves the traffic then
+func (f *GitHubFetcher) FetchTraffic(ctx context.Context, repoName string) (*Traffic, error) {
+	owner, repo, err := splitRepoName(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	r, _, err := f.client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	if !r.GetPermissions()["push"] {
+		return nil, ErrNoPushAccess
+	}
+
+	opt := &github.TrafficBreakdownOptions{Per: "day"}
+	views, _, err := f.client.Repositories.ListTrafficViews(ctx, owner, repo, opt)
+	if err != nil {
+		return nil, err
+	}
+	clones, _, err := f.client.Repositories.ListTrafficClones(ctx, owner, repo, opt)
+	if err != nil {
+		return nil, err
+	}
+	referrers, _, err := f.client.Repositories.ListTrafficReferrers(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	traffic := &Traffic{
+		Views:          int64(views.GetCount()),
+		UniqueVisitors: int64(views.GetUniques()),
+		Clones:         int64(clones.GetCount()),
+		UniqueCloners:  int64(clones.GetUniques()),
+	}
+	days := map[string]*TrafficDay{}
+	day := func(data *github.TrafficData) *TrafficDay {
+		start := data.GetTimestamp().UTC().Truncate(24 * time.Hour)
+		key := start.Format(trafficDayLayout)
+		if days[key] == nil {
+			days[key] = &TrafficDay{Day: start}
+		}
+		return days[key]
+	}
+	for _, data := range views.Views {
+		d := day(data)
+		d.Views, d.UniqueVisitors = int64(data.GetCount()), int64(data.GetUniques())
+	}
+	for _, data := range clones.Clones {
+		d := day(data)
+		d.Clones, d.UniqueCloners = int64(data.GetCount()), int64(data.GetUniques())
+	}
+	for _, d := range days {
+		traffic.Days = append(traffic.Days, *d)
+	}
+	sort.Slice(traffic.Days, func(i, j int) bool { return traffic.Days[i].Day.Before(traffic.Days[j].Day) })
+
+	for _, referrer := range referrers {
+		traffic.Referrers = append(traffic.Referrers, Referrer{
+			Referrer:       referrer.GetReferrer(),
+			Views:          int64(referrer.GetCount()),
+			UniqueVisitors: int64(referrer.GetUniques()),
+		})
+	}
+	sortReferrers(traffic.Referrers)
+	return traffic, nil
+}
+
+// sortReferrers sorts referrers by views, most first, then by name
+func sortReferrers(referrers []Referrer) {
+	sort.Slice(referrers, func(i, j int) bool {
+		if referrers[i].Views != referrers[j].Views {
+			return referrers[i].Views > referrers[j].Views
+		}
+		return referrers[i].Referrer < referrers[j].Referrer
+	})
+}
+
+// trafficHistory is the traffic of a repo kept in the companion ConfigMap
+type trafficHistory struct {
+	// Days are the daily views and clones, oldest first
+	Days []trafficHistoryDay `json:"days"`
+	// Referrers are the top referrers of the 14 days before the last fetch
+	Referrers []trafficReferrer `json:"referrers,omitempty"`
+}
+
+type trafficHistoryDay struct {
+	// Date is like 2006-01-02 in UTC
+	Date           string `json:"date"`
+	Views          int64  `json:"views"`
+	UniqueVisitors int64  `json:"uniqueVisitors"`
+	Clones         int64  `json:"clones"`
+	UniqueCloners  int64  `json:"uniqueCloners"`
+}
+
+type trafficReferrer struct {
+	Referrer       string `json:"referrer"`
+	Views          int64  `json:"views"`
+	UniqueVisitors int64  `json:"uniqueVisitors"`
+}
+
+// merge adds the fetched days to the history, they replace the kept days of the same date since the numbers of the
+// current day grow until it ends, and the referrers replace the kept ones
+func (h *trafficHistory) merge(traffic *Traffic) {
+	days := map[string]trafficHistoryDay{}
+	for _, d := range h.Days {
+		days[d.Date] = d
+	}
+	for _, d := range traffic.Days {
+		date := d.Day.UTC().Format(trafficDayLayout)
+		days[date] = trafficHistoryDay{
+			Date:           date,
+			Views:          d.Views,
+			UniqueVisitors: d.UniqueVisitors,
+			Clones:         d.Clones,
+			UniqueCloners:  d.UniqueCloners,
+		}
+	}
+
+	h.Days = h.Days[:0]
+	for _, d := range days {
+		h.Days = append(h.Days, d)
+	}
+	// the dates sort like the days
+	sort.Slice(h.Days, func(i, j int) bool { return h.Days[i].Date < h.Days[j].Date })
+	if len(h.Days) > maxTrafficDays {
+		h.Days = h.Days[len(h.Days)-maxTrafficDays:]
+	}
+
+	h.Referrers = nil
+	for _, r := range traffic.Referrers {
+		h.Referrers = append(h.Referrers, trafficReferrer(r))
+	}
+}
+
+// refreshTraffic adds the traffic of the repos of gitStar the token has push access to to the companion ConfigMap
+// and reports the last 14 days in status.traffic, a failure keeps the previous report
+func (r *Runner) refreshTraffic(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) {
+	if !gitStar.Spec.TrackTraffic {
+		gitStar.Status.Traffic = nil
+		return
+	}
+
+	status, err := r.recordTraffic(ctx, gitStar, fetcher)
+	if err != nil {
+		log.Error(err, "record traffic failed! ", "Request.Namespace", gitStar.Namespace, "Request.Name", gitStar.Name)
+		previous := gitStar.Status.Traffic.DeepCopy()
+		if previous == nil {
+			previous = &appV1.GitStarTrafficStatus{}
+		}
+		if status != nil {
+			previous.TrafficName = status.TrafficName
+			previous.Days = status.Days
+			previous.SkippedRepos = status.SkippedRepos
+		}
+		previous.FailedReason = err.Error()
+		status = previous
+	}
+	gitStar.Status.Traffic = status
+}
+
+// recordTraffic saves the traffic of the repos fetched successfully, on failure the returned status only has the
+// ConfigMap, its days and the skipped repos set
+func (r *Runner) recordTraffic(ctx context.Context, gitStar *appV1.GitStar, fetcher RepoStatsFetcher) (*appV1.GitStarTrafficStatus, error) {
+	trafficFetcher, ok := fetcher.(TrafficFetcher)
+	if !ok {
+		return nil, errors.New("the fetcher can't fetch traffic")
+	}
+
+	cm, err := resource.GetCompanion(ctx, r.Client, gitStar, TrafficCompanion)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &appV1.GitStarTrafficStatus{TrafficName: cm.Name, UpdatedAt: metav1.NewTime(time.Now())}
+	referrers := map[string]*Referrer{}
+	dates := map[string]bool{}
+	var failures []string
+	data := map[string]string{}
+	for _, repoName := range gitStar.Spec.RepoNames() {
+		history := trafficHistory{}
+		if raw, ok := cm.Data[repoKey(repoName)]; ok {
+			if err := json.Unmarshal([]byte(raw), &history); err != nil {
+				log.Error(err, "parse traffic failed! ", "Repo", repoName)
+				history = trafficHistory{}
+			}
+		}
+
+		traffic, err := trafficFetcher.FetchTraffic(ctx, repoName)
+		switch {
+		case err == ErrNoPushAccess:
+			status.SkippedRepos = append(status.SkippedRepos, repoName)
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("%s: %v", repoName, err))
+		default:
+			history.merge(traffic)
+			status.Views += traffic.Views
+			status.UniqueVisitors += traffic.UniqueVisitors
+			status.Clones += traffic.Clones
+			status.UniqueCloners += traffic.UniqueCloners
+			for _, referrer := range traffic.Referrers {
+				if referrers[referrer.Referrer] == nil {
+					referrers[referrer.Referrer] = &Referrer{Referrer: referrer.Referrer}
+				}
+				referrers[referrer.Referrer].Views += referrer.Views
+				referrers[referrer.Referrer].UniqueVisitors += referrer.UniqueVisitors
+			}
+		}
+
+		// a repo without push access keeps the traffic recorded before
+		if len(history.Days) == 0 {
+			continue
+		}
+		raw, err := json.Marshal(history)
+		if err != nil {
+			return nil, err
+		}
+		data[repoKey(repoName)] = string(raw)
+		for _, d := range history.Days {
+			dates[d.Date] = true
+		}
+	}
+	status.Days = int64(len(dates))
+
+	if !r.DryRun {
+		cm.Data = data
+		if err := resource.SaveCompanion(ctx, r.Client, cm); err != nil {
+			return nil, fmt.Errorf("save traffic failed: %v", err)
+		}
+	}
+	// the totals of some repos would look like a drop
+	if len(failures) > 0 {
+		return status, errors.New(strings.Join(failures, "; "))
+	}
+
+	var top []Referrer
+	for _, referrer := range referrers {
+		top = append(top, *referrer)
+	}
+	sortReferrers(top)
+	if len(top) > maxReportedReferrers {
+		top = top[:maxReportedReferrers]
+	}
+	for _, referrer := range top {
+		status.TopReferrers = append(status.TopReferrers, appV1.GitStarReferrer(referrer))
+	}
+	return status, nil
+}
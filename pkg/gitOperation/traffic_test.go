@@ -0,0 +1,157 @@
+package gitOperation
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appV1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/fakegithub"
+)
+
+var trafficStart = time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestTraffic returns a repo with push access and the traffic of the 14 days from start
+func newTestTraffic(start time.Time) fakegithub.Repo {
+	repo := fakegithub.Repo{Owner: "kuri-su", Name: "kblog", Stars: 42, Push: true, Referrers: []fakegithub.Referrer{
+		{Referrer: "google.com", Count: 20, Uniques: 8},
+		{Referrer: "github.com", Count: 30, Uniques: 10},
+	}}
+	for i := 0; i < 14; i++ {
+		day := start.AddDate(0, 0, i)
+		repo.Views = append(repo.Views, fakegithub.Traffic{Day: day, Count: 10, Uniques: 2})
+		if i%2 == 0 {
+			repo.Clones = append(repo.Clones, fakegithub.Traffic{Day: day, Count: 3, Uniques: 1})
+		}
+	}
+	return repo
+}
+
+func TestFetchTraffic(t *testing.T) {
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddRepo(newTestTraffic(trafficStart))
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "forked"})
+
+	f := newTestFetcher(t, server).(*GitHubFetcher)
+	traffic, err := f.FetchTraffic(context.TODO(), "kuri-su/kblog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if traffic.Views != 140 || traffic.UniqueVisitors != 28 || traffic.Clones != 21 || traffic.UniqueCloners != 7 || len(traffic.Days) != 14 {
+		t.Errorf("traffic = %+v, want 140 views, 28 visitors, 21 clones and 7 cloners over 14 days", traffic)
+	}
+	want := TrafficDay{Day: trafficStart, Views: 10, UniqueVisitors: 2, Clones: 3, UniqueCloners: 1}
+	if !traffic.Days[0].Day.Equal(want.Day) || traffic.Days[0].Views != want.Views || traffic.Days[0].Clones != want.Clones ||
+		traffic.Days[1].Clones != 0 {
+		t.Errorf("days = %+v, want %+v first and no clones on the second day", traffic.Days[:2], want)
+	}
+	if len(traffic.Referrers) != 2 || traffic.Referrers[0].Referrer != "github.com" {
+		t.Errorf("referrers = %+v, want github.com first", traffic.Referrers)
+	}
+
+	if _, err := f.FetchTraffic(context.TODO(), "kuri-su/forked"); err != ErrNoPushAccess {
+		t.Errorf("err = %v, want ErrNoPushAccess", err)
+	}
+}
+
+func TestTrafficHistoryMerge(t *testing.T) {
+	h := trafficHistory{Days: []trafficHistoryDay{
+		{Date: "2020-04-30", Views: 1},
+		{Date: "2020-05-01", Views: 2},
+	}}
+	h.merge(&Traffic{
+		Days: []TrafficDay{
+			{Day: trafficStart, Views: 5},
+			{Day: trafficStart.AddDate(0, 0, 1), Views: 7},
+		},
+		Referrers: []Referrer{{Referrer: "github.com", Views: 3, UniqueVisitors: 1}},
+	})
+	want := trafficHistory{
+		Days: []trafficHistoryDay{
+			{Date: "2020-04-30", Views: 1},
+			{Date: "2020-05-01", Views: 5},
+			{Date: "2020-05-02", Views: 7},
+		},
+		Referrers: []trafficReferrer{{Referrer: "github.com", Views: 3, UniqueVisitors: 1}},
+	}
+	if !reflect.DeepEqual(h, want) {
+		t.Errorf("history = %+v, want %+v", h, want)
+	}
+
+	// the oldest days are dropped
+	var days []TrafficDay
+	for i := 0; i < maxTrafficDays+5; i++ {
+		days = append(days, TrafficDay{Day: trafficStart.AddDate(0, 0, i)})
+	}
+	h.merge(&Traffic{Days: days})
+	if len(h.Days) != maxTrafficDays || h.Days[0].Date != trafficStart.AddDate(0, 0, 5).Format(trafficDayLayout) {
+		t.Errorf("history has %d days from %s, want %d days", len(h.Days), h.Days[0].Date, maxTrafficDays)
+	}
+}
+
+func TestRunnerTrackTraffic(t *testing.T) {
+	SetFetchLimits(FetchLimits{})
+	defer SetFetchLimits(DefaultFetchLimits())
+	server := fakegithub.NewServer()
+	defer server.Close()
+	server.AddRepo(newTestTraffic(trafficStart))
+	server.AddRepo(fakegithub.Repo{Owner: "kuri-su", Name: "kblog-ui", Stars: 3})
+	factory, err := GitHubFetcherFactoryForURL(server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitStar := newTestGitStar("default", "kblog", "kuri-su/kblog")
+	gitStar.Spec.Repositories = []string{"kuri-su/kblog-ui"}
+	gitStar.Spec.TrackTraffic = true
+	c := fake.NewFakeClientWithScheme(newTestScheme(t), gitStar)
+	runner := &Runner{Client: c, NewFetcher: factory}
+	run := func() (*appV1.GitStarTrafficStatus, trafficHistory) {
+		if err := runner.Run("default", "kblog"); err != nil {
+			t.Fatal(err)
+		}
+		got := &appV1.GitStar{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog"}, got); err != nil {
+			t.Fatal(err)
+		}
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "kblog-traffic"}, cm); err != nil {
+			t.Fatal(err)
+		}
+		history := trafficHistory{}
+		if err := json.Unmarshal([]byte(cm.Data[repoKey("kuri-su/kblog")]), &history); err != nil {
+			t.Fatal(err)
+		}
+		return got.Status.Traffic, history
+	}
+
+	status, history := run()
+	if status == nil || status.Views != 140 || status.Clones != 21 || status.Days != 14 || status.FailedReason != "" ||
+		!reflect.DeepEqual(status.SkippedRepos, []string{"kuri-su/kblog-ui"}) || status.TrafficName != "kblog-traffic" {
+		t.Fatalf("traffic = %+v, want 140 views and 21 clones over 14 days, kblog-ui skipped", status)
+	}
+	if len(status.TopReferrers) != 2 || status.TopReferrers[0].Referrer != "github.com" || len(history.Days) != 14 {
+		t.Fatalf("referrers = %+v, %d days kept, want github.com first and 14 days", status.TopReferrers, len(history.Days))
+	}
+
+	// a week later GitHub dropped the first days, the ConfigMap keeps them
+	server.AddRepo(newTestTraffic(trafficStart.AddDate(0, 0, 7)))
+	status, history = run()
+	if status.Days != 21 || len(history.Days) != 21 || history.Days[0].Date != "2020-05-01" {
+		t.Fatalf("traffic = %+v, %d days kept, want 21 days from 2020-05-01", status, len(history.Days))
+	}
+
+	// a failed fetch keeps the traffic
+	server.SetError("/repos/kuri-su/kblog/traffic/views", 502)
+	status, history = run()
+	if status.Views != 140 || status.Days != 21 || status.FailedReason == "" || len(history.Days) != 21 {
+		t.Errorf("traffic = %+v, want the previous traffic with the failure", status)
+	}
+}
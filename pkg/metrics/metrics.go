@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// GitStarRank is the rank of a GitStar in a GitStarLeaderboard
+	GitStarRank = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitstar_rank",
+		Help: "Rank of a GitStar in a GitStarLeaderboard, 1 is the first place",
+	}, []string{"namespace", "leaderboard", "gitstar", "repo"})
+
+	// GitStarPullRequests counts the pull requests of the repos of a GitStar with spec.trackActivity by state
+	GitStarPullRequests = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitstar_pull_requests",
+		Help: "Pull requests of the repos of a GitStar by state, open or closed",
+	}, []string{"namespace", "gitstar", "state"})
+
+	// GitStarIssuesLast30Days counts the issues of the repos of a GitStar opened and closed in the last 30 days
+	GitStarIssuesLast30Days = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitstar_issues_last_30_days",
+		Help: "Issues of the repos of a GitStar opened or closed in the last 30 days",
+	}, []string{"namespace", "gitstar", "event"})
+
+	// GitStarFirstResponseSeconds is the median time to the first response to the issues and pull requests of a
+	// GitStar
+	GitStarFirstResponseSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitstar_first_response_median_seconds",
+		Help: "Median time to the first response to the issues and pull requests of the repos of a GitStar opened in the last 30 days",
+	}, []string{"namespace", "gitstar"})
+
+	// GitStarContributors counts the contributors of the repos of a GitStar with spec.trackContributors
+	GitStarContributors = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitstar_contributors",
+		Help: "Contributors of the repos of a GitStar, summed over the repos",
+	}, []string{"namespace", "gitstar"})
+
+	// GitStarTopContributorCommits counts the commits of the top contributors of a GitStar
+	GitStarTopContributorCommits = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitstar_top_contributor_commits",
+		Help: "Commits of a top contributor to the default branches of the repos of a GitStar",
+	}, []string{"namespace", "gitstar", "login"})
+
+	// GitStarTrafficViews counts the views of the repos of a GitStar with spec.trackTraffic in the last 14 days
+	GitStarTrafficViews = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitstar_traffic_views_last_14_days",
+		Help: "Views of the repos of a GitStar in the last 14 days, total or unique visitors",
+	}, []string{"namespace", "gitstar", "count"})
+
+	// GitStarTrafficClones counts the clones of the repos of a GitStar with spec.trackTraffic in the last 14 days
+	GitStarTrafficClones = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitstar_traffic_clones_last_14_days",
+		Help: "Clones of the repos of a GitStar in the last 14 days, total or unique cloners",
+	}, []string{"namespace", "gitstar", "count"})
+
+	// GitStarTrafficReferrerViews counts the views the top referrers of a GitStar referred in the last 14 days
+	GitStarTrafficReferrerViews = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitstar_traffic_referrer_views_last_14_days",
+		Help: "Views of the repos of a GitStar referred by a top referrer in the last 14 days",
+	}, []string{"namespace", "gitstar", "referrer"})
+)
+
+func init() {
+	// Register the metrics with the registry served by the manager on the metrics port
+	metrics.Registry.MustRegister(GitStarRank, GitStarPullRequests, GitStarIssuesLast30Days, GitStarFirstResponseSeconds,
+		GitStarContributors, GitStarTopContributorCommits, GitStarTrafficViews, GitStarTrafficClones, GitStarTrafficReferrerViews)
+}
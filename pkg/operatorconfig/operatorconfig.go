@@ -0,0 +1,167 @@
+// Package operatorconfig resolves the settings of the GitStars from the GitStarOperatorConfig of the operator
+package operatorconfig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+const (
+	// DefaultSchedule is the cron schedule of the queryJobs, every hour
+	DefaultSchedule = "10 * * * *"
+
+	DefaultCredentialsNamespace = "default"
+	DefaultCredentialsName      = "gitstar-github-token"
+	DefaultCredentialsKey       = "token"
+
+	DefaultJobsHistoryLimit int32 = 3
+)
+
+// Name is the name of the GitStarOperatorConfig read by the operator
+var Name = "gitstar-operator"
+
+var log = logf.Log.WithName("operatorconfig")
+
+// Settings are the effective settings of the GitStars of a namespace
+type Settings struct {
+	Schedule    string
+	Credentials appv1.CredentialsReference
+	// JobTemplate is overlaid by spec.jobTemplate of the GitStars, nil when it isn't configured
+	JobTemplate                *appv1.GitStarJobTemplate
+	SuccessfulJobsHistoryLimit int32
+	FailedJobsHistoryLimit     int32
+}
+
+// DefaultSettings returns the settings used without a GitStarOperatorConfig
+func DefaultSettings() Settings {
+	return Settings{
+		Schedule: DefaultSchedule,
+		Credentials: appv1.CredentialsReference{
+			Namespace: DefaultCredentialsNamespace,
+			Name:      DefaultCredentialsName,
+			Key:       DefaultCredentialsKey,
+		},
+		SuccessfulJobsHistoryLimit: DefaultJobsHistoryLimit,
+		FailedJobsHistoryLimit:     DefaultJobsHistoryLimit,
+	}
+}
+
+// Get reads the GitStarOperatorConfig of the operator, it returns nil without error when it doesn't exist
+func Get(ctx context.Context, reader client.Reader) (*appv1.GitStarOperatorConfig, error) {
+	config := &appv1.GitStarOperatorConfig{}
+	err := reader.Get(ctx, types.NamespacedName{Name: Name}, config)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// SettingsFor reads the GitStarOperatorConfig and resolves the settings of namespace, the defaults are used when
+// the config doesn't exist, can't be read or is invalid
+func SettingsFor(ctx context.Context, reader client.Reader, namespace string) Settings {
+	config, err := Get(ctx, reader)
+	if err != nil {
+		// e.g. the CRD isn't installed or the reader isn't allowed to read it, the credentials may be the wrong ones
+		log.Error(err, "read GitStarOperatorConfig failed, use the defaults", "GitStarOperatorConfig.Name", Name)
+		return DefaultSettings()
+	}
+	if config == nil {
+		return DefaultSettings()
+	}
+	if err := Validate(&config.Spec); err != nil {
+		log.Error(err, "invalid GitStarOperatorConfig, use the defaults")
+		return DefaultSettings()
+	}
+	return Resolve(&config.Spec, namespace)
+}
+
+// Resolve returns the settings of spec for namespace, the override of namespace replaces the set fields of spec
+func Resolve(spec *appv1.GitStarOperatorConfigSpec, namespace string) Settings {
+	settings := DefaultSettings()
+	if spec == nil {
+		return settings
+	}
+	apply(&settings, &spec.GitStarOperatorSettings)
+	for i := range spec.NamespaceOverrides {
+		if spec.NamespaceOverrides[i].Namespace == namespace {
+			apply(&settings, &spec.NamespaceOverrides[i].GitStarOperatorSettings)
+		}
+	}
+	return settings
+}
+
+func apply(settings *Settings, overlay *appv1.GitStarOperatorSettings) {
+	if overlay.Schedule != "" {
+		settings.Schedule = overlay.Schedule
+	}
+	if c := overlay.Credentials; c != nil {
+		if c.Namespace != "" {
+			settings.Credentials.Namespace = c.Namespace
+		}
+		if c.Name != "" {
+			settings.Credentials.Name = c.Name
+		}
+		if c.Key != "" {
+			settings.Credentials.Key = c.Key
+		}
+	}
+	if overlay.JobTemplate != nil {
+		// the job template of an override replaces the one of the config as a whole
+		settings.JobTemplate = overlay.JobTemplate.DeepCopy()
+	}
+	if overlay.SuccessfulJobsHistoryLimit != nil {
+		settings.SuccessfulJobsHistoryLimit = *overlay.SuccessfulJobsHistoryLimit
+	}
+	if overlay.FailedJobsHistoryLimit != nil {
+		settings.FailedJobsHistoryLimit = *overlay.FailedJobsHistoryLimit
+	}
+}
+
+// Validate checks the spec of a GitStarOperatorConfig
+func Validate(spec *appv1.GitStarOperatorConfigSpec) error {
+	if err := validateSettings(&spec.GitStarOperatorSettings); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for i := range spec.NamespaceOverrides {
+		override := &spec.NamespaceOverrides[i]
+		if override.Namespace == "" {
+			return fmt.Errorf("namespaceOverrides[%d].namespace is empty", i)
+		}
+		if seen[override.Namespace] {
+			return fmt.Errorf("namespaceOverrides[%d].namespace '%s' is duplicated", i, override.Namespace)
+		}
+		seen[override.Namespace] = true
+		if err := validateSettings(&override.GitStarOperatorSettings); err != nil {
+			return fmt.Errorf("namespaceOverrides[%d]: %v", i, err)
+		}
+	}
+	for _, port := range []*int32{spec.MetricsPort, spec.OperatorMetricsPort} {
+		if port != nil && (*port <= 0 || *port > 65535) {
+			return fmt.Errorf("port %d is invalid", *port)
+		}
+	}
+	return nil
+}
+
+func validateSettings(settings *appv1.GitStarOperatorSettings) error {
+	if settings.Schedule != "" && len(strings.Fields(settings.Schedule)) != 5 && !strings.HasPrefix(settings.Schedule, "@") {
+		return fmt.Errorf("schedule '%s' is invalid, must be a cron expression of 5 fields", settings.Schedule)
+	}
+	for _, limit := range []*int32{settings.SuccessfulJobsHistoryLimit, settings.FailedJobsHistoryLimit} {
+		if limit != nil && *limit < 0 {
+			return fmt.Errorf("history limit %d is negative", *limit)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package operatorconfig
+
+import (
+	"testing"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func TestResolve(t *testing.T) {
+	spec := &appv1.GitStarOperatorConfigSpec{
+		GitStarOperatorSettings: appv1.GitStarOperatorSettings{
+			Schedule:               "0 * * * *",
+			Credentials:            &appv1.CredentialsReference{Namespace: "gitstar-system"},
+			JobTemplate:            &appv1.GitStarJobTemplate{Image: "queryjob:v1", ServiceAccountName: "gitstar"},
+			FailedJobsHistoryLimit: int32Ptr(1),
+		},
+		NamespaceOverrides: []appv1.GitStarNamespaceOverride{{
+			Namespace: "team-a",
+			GitStarOperatorSettings: appv1.GitStarOperatorSettings{
+				Schedule:    "*/15 * * * *",
+				Credentials: &appv1.CredentialsReference{Name: "team-a-token"},
+				JobTemplate: &appv1.GitStarJobTemplate{Image: "queryjob:v2"},
+			},
+		}},
+	}
+
+	settings := Resolve(spec, "default")
+	if settings.Schedule != "0 * * * *" || settings.FailedJobsHistoryLimit != 1 ||
+		settings.SuccessfulJobsHistoryLimit != DefaultJobsHistoryLimit {
+		t.Errorf("settings = %+v, want the config over the defaults", settings)
+	}
+	if settings.Credentials != (appv1.CredentialsReference{Namespace: "gitstar-system", Name: DefaultCredentialsName, Key: DefaultCredentialsKey}) {
+		t.Errorf("credentials = %+v, want the namespace of the config", settings.Credentials)
+	}
+
+	settings = Resolve(spec, "team-a")
+	if settings.Schedule != "*/15 * * * *" || settings.FailedJobsHistoryLimit != 1 {
+		t.Errorf("settings = %+v, want the override over the config", settings)
+	}
+	if settings.Credentials.Namespace != "gitstar-system" || settings.Credentials.Name != "team-a-token" {
+		t.Errorf("credentials = %+v, want the override merged into the config", settings.Credentials)
+	}
+	if settings.JobTemplate.Image != "queryjob:v2" || settings.JobTemplate.ServiceAccountName != "" {
+		t.Errorf("jobTemplate = %+v, want the one of the override as a whole", settings.JobTemplate)
+	}
+
+	if settings := Resolve(nil, "default"); settings.Schedule != DefaultSchedule || settings.JobTemplate != nil {
+		t.Errorf("settings = %+v, want the defaults", settings)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, spec := range map[string]appv1.GitStarOperatorConfigSpec{
+		"schedule":        {GitStarOperatorSettings: appv1.GitStarOperatorSettings{Schedule: "every hour"}},
+		"limit":           {GitStarOperatorSettings: appv1.GitStarOperatorSettings{SuccessfulJobsHistoryLimit: int32Ptr(-1)}},
+		"port":            {MetricsPort: int32Ptr(70000)},
+		"empty namespace": {NamespaceOverrides: []appv1.GitStarNamespaceOverride{{}}},
+		"duplicated namespace": {NamespaceOverrides: []appv1.GitStarNamespaceOverride{
+			{Namespace: "team-a"}, {Namespace: "team-a"},
+		}},
+		"override": {NamespaceOverrides: []appv1.GitStarNamespaceOverride{{
+			Namespace:               "team-a",
+			GitStarOperatorSettings: appv1.GitStarOperatorSettings{FailedJobsHistoryLimit: int32Ptr(-1)},
+		}}},
+	} {
+		spec := spec
+		if err := Validate(&spec); err == nil {
+			t.Errorf("%s: Validate() = nil, want an error", name)
+		}
+	}
+
+	valid := appv1.GitStarOperatorConfigSpec{
+		GitStarOperatorSettings: appv1.GitStarOperatorSettings{Schedule: "@hourly"},
+		NamespaceOverrides:      []appv1.GitStarNamespaceOverride{{Namespace: "team-a"}},
+		MetricsPort:             int32Ptr(8383),
+	}
+	if err := Validate(&valid); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
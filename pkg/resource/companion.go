@@ -0,0 +1,77 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+const (
+	// CompanionLabel names the GitStar owning a companion ConfigMap
+	CompanionLabel = "app.kuricat.com/gitstar"
+
+	// MaxCompanionSize is the size of the data a companion ConfigMap can hold, objects are limited to 1MiB and the
+	// rest is left to the metadata
+	MaxCompanionSize = 1000 * 1000
+)
+
+// Companion ConfigMaps keep the data of a GitStar too large for its status, like the stargazers of its repos. They
+// are owned by the GitStar, so they are garbage collected with it.
+
+// CompanionName returns the name of the companion ConfigMap of cr holding kind, like "kblog-stargazers"
+func CompanionName(cr *appv1.GitStar, kind string) string {
+	return fmt.Sprintf("%s-%s", cr.Name, kind)
+}
+
+// GetCompanion reads the companion ConfigMap of cr holding kind, a new unsaved one when it doesn't exist
+func GetCompanion(ctx context.Context, c client.Reader, cr *appv1.GitStar, kind string) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: cr.Namespace, Name: CompanionName(cr, kind)}, cm)
+	if k8serrors.IsNotFound(err) {
+		return newCompanion(cr, kind), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// SaveCompanion creates or updates a companion ConfigMap read by GetCompanion, it fails without a request when the
+// data is larger than MaxCompanionSize
+func SaveCompanion(ctx context.Context, c client.Writer, cm *corev1.ConfigMap) error {
+	size := 0
+	for key, value := range cm.Data {
+		size += len(key) + len(value)
+	}
+	if size > MaxCompanionSize {
+		return fmt.Errorf("the data of ConfigMap %s is %d bytes, more than the %d a companion can hold", cm.Name, size, MaxCompanionSize)
+	}
+
+	if cm.ResourceVersion == "" {
+		return c.Create(ctx, cm)
+	}
+	return c.Update(ctx, cm)
+}
+
+func newCompanion(cr *appv1.GitStar, kind string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CompanionName(cr, kind),
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				CompanionLabel: cr.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(cr, appv1.SchemeGroupVersion.WithKind("GitStar")),
+			},
+		},
+		Data: map[string]string{},
+	}
+}
@@ -0,0 +1,41 @@
+package resource
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+func TestSaveCompanion(t *testing.T) {
+	c := fake.NewFakeClient()
+	gitStar := &appv1.GitStar{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kblog", UID: "kblog-uid"}}
+	key := types.NamespacedName{Namespace: "default", Name: "kblog-stargazers"}
+
+	cm, err := GetCompanion(context.TODO(), c, gitStar, "stargazers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm.Data["kuri-su_kblog.json"] = strings.Repeat("x", MaxCompanionSize)
+	if err := SaveCompanion(context.TODO(), c, cm); err == nil {
+		t.Error("data is larger than a companion can hold, want error")
+	}
+	if err := c.Get(context.TODO(), key, &corev1.ConfigMap{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("err = %v, want the companion not created", err)
+	}
+
+	cm.Data["kuri-su_kblog.json"] = `{"stargazers":[]}`
+	if err := SaveCompanion(context.TODO(), c, cm); err != nil {
+		t.Fatal(err)
+	}
+	if cm, err = GetCompanion(context.TODO(), c, gitStar, "stargazers"); err != nil || cm.ResourceVersion == "" {
+		t.Errorf("companion = %+v, %v, want it saved", cm, err)
+	}
+}
@@ -2,63 +2,220 @@ package resource
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"sync"
 
 	v1 "k8s.io/api/batch/v1"
 	batchv1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
 
 	appv1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/operatorconfig"
 )
 
 const (
 	ENVGitStarName      = "git_star_name"
 	ENVGitStarNameSpace = "git_star_name_space"
+	// ENVGitStarConfigName names the GitStarOperatorConfig the queryJob reads the credentials from
+	ENVGitStarConfigName = "git_star_config_name"
+	// ENVGitStarCredentialsNamespace, ENVGitStarCredentialsName and ENVGitStarCredentialsKey pass the token ConfigMap
+	// resolved by the operator, the service account of the queryJob may not be allowed to read the config
+	ENVGitStarCredentialsNamespace = "git_star_credentials_namespace"
+	ENVGitStarCredentialsName      = "git_star_credentials_name"
+	ENVGitStarCredentialsKey       = "git_star_credentials_key"
+
+	// SpecHashAnnotation records the hash of the spec of a CronJob, the CronJob is updated when the spec for its
+	// GitStar hashes differently
+	SpecHashAnnotation = "gitstar.app.kuricat.com/spec-hash"
 )
 
 var (
-	CronJobHistoryLimit int32 = 3
+	// DefaultJobImage and DefaultJobServiceAccountName are used unless the operator or the GitStar configures them
+	DefaultJobImage              = "kurisux/gitstar-queryjob:latest"
+	DefaultJobServiceAccountName = "gitstar-operator"
+
+	jobDefaultsMu sync.RWMutex
+	jobDefaults   = appv1.GitStarJobTemplate{
+		Image:              DefaultJobImage,
+		ServiceAccountName: DefaultJobServiceAccountName,
+	}
 
 	log = logf.Log.WithName("controller_gitstar")
 )
 
-// newCronJobForCR
-func NewCronJobForCR(cr *appv1.GitStar) *batchv1.CronJob {
+// SetJobDefaults overlays the built-in defaults of the queryJob pods with the defaults of the operator
+func SetJobDefaults(defaults appv1.GitStarJobTemplate) {
+	jobDefaultsMu.Lock()
+	defer jobDefaultsMu.Unlock()
+	jobDefaults = MergeJobTemplate(appv1.GitStarJobTemplate{
+		Image:              DefaultJobImage,
+		ServiceAccountName: DefaultJobServiceAccountName,
+	}, &defaults)
+}
+
+// JobDefaults returns the defaults of the queryJob pods
+func JobDefaults() appv1.GitStarJobTemplate {
+	jobDefaultsMu.RLock()
+	defer jobDefaultsMu.RUnlock()
+	return *jobDefaults.DeepCopy()
+}
+
+// LoadJobTemplate reads a GitStarJobTemplate from a YAML or JSON file, e.g. a mounted ConfigMap
+func LoadJobTemplate(path string) (*appv1.GitStarJobTemplate, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	template := &appv1.GitStarJobTemplate{}
+	if err := yaml.UnmarshalStrict(data, template); err != nil {
+		return nil, fmt.Errorf("parse job template '%s' failed: %v", path, err)
+	}
+	return template, nil
+}
+
+// MergeJobTemplate returns base overlaid by the set fields of overlay, env vars are merged by name
+func MergeJobTemplate(base appv1.GitStarJobTemplate, overlay *appv1.GitStarJobTemplate) appv1.GitStarJobTemplate {
+	merged := *base.DeepCopy()
+	if overlay == nil {
+		return merged
+	}
+	overlay = overlay.DeepCopy()
+
+	if overlay.Image != "" {
+		merged.Image = overlay.Image
+	}
+	if overlay.ImagePullPolicy != "" {
+		merged.ImagePullPolicy = overlay.ImagePullPolicy
+	}
+	if overlay.ImagePullSecrets != nil {
+		merged.ImagePullSecrets = overlay.ImagePullSecrets
+	}
+	if overlay.ServiceAccountName != "" {
+		merged.ServiceAccountName = overlay.ServiceAccountName
+	}
+	if overlay.Resources != nil {
+		merged.Resources = overlay.Resources
+	}
+	if overlay.NodeSelector != nil {
+		merged.NodeSelector = overlay.NodeSelector
+	}
+	if overlay.Tolerations != nil {
+		merged.Tolerations = overlay.Tolerations
+	}
+	if overlay.SecurityContext != nil {
+		merged.SecurityContext = overlay.SecurityContext
+	}
+	if overlay.PodSecurityContext != nil {
+		merged.PodSecurityContext = overlay.PodSecurityContext
+	}
+	merged.Env = mergeEnv(merged.Env, overlay.Env)
+	return merged
+}
+
+// mergeEnv replaces the env vars of base with the ones of overlay having the same name and appends the others
+func mergeEnv(base, overlay []corev1.EnvVar) []corev1.EnvVar {
+	merged := append([]corev1.EnvVar(nil), base...)
+	for _, env := range overlay {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == env.Name {
+				merged[i] = env
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, env)
+		}
+	}
+	return merged
+}
+
+// newCronJobForCR builds the CronJob of cr with the settings of its namespace, the defaults when settings is nil
+func NewCronJobForCR(cr *appv1.GitStar, settings *operatorconfig.Settings) *batchv1.CronJob {
 	labels := map[string]string{
 		"app": cr.Name,
 	}
+	if settings == nil {
+		defaults := operatorconfig.DefaultSettings()
+		settings = &defaults
+	}
+	// the flags of the operator, then the GitStarOperatorConfig, then the GitStar
+	template := MergeJobTemplate(MergeJobTemplate(JobDefaults(), settings.JobTemplate), cr.Spec.JobTemplate)
+	schedule := settings.Schedule
+	if cr.Schedule() != "" {
+		// spec.schedule of the v2 GitStar
+		schedule = cr.Schedule()
+	}
+	successfulJobsHistoryLimit := settings.SuccessfulJobsHistoryLimit
+	failedJobsHistoryLimit := settings.FailedJobsHistoryLimit
+	// the env naming the GitStar, the GitStarOperatorConfig and the credentials can't be overridden
+	env := mergeEnv(template.Env, []corev1.EnvVar{
+		{
+			Name:  ENVGitStarName,
+			Value: cr.Name,
+		},
+		{
+			Name:  ENVGitStarNameSpace,
+			Value: cr.Namespace,
+		},
+		{
+			Name:  ENVGitStarConfigName,
+			Value: operatorconfig.Name,
+		},
+		{
+			Name:  ENVGitStarCredentialsNamespace,
+			Value: settings.Credentials.Namespace,
+		},
+		{
+			Name:  ENVGitStarCredentialsName,
+			Value: settings.Credentials.Name,
+		},
+		{
+			Name:  ENVGitStarCredentialsKey,
+			Value: settings.Credentials.Key,
+		},
+	})
+	resources := corev1.ResourceRequirements{}
+	if template.Resources != nil {
+		resources = *template.Resources
+	}
 
-	return &batchv1.CronJob{
+	cronJob := &batchv1.CronJob{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GenerateCronJobName(cr),
 			Namespace: cr.Namespace,
 			Labels:    labels,
 		},
 		Spec: batchv1.CronJobSpec{
-			Schedule: "10 * * * *", // every 1 hour
+			Schedule: schedule,
 			JobTemplate: batchv1.JobTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{},
 				Spec: v1.JobSpec{
 					Template: corev1.PodTemplateSpec{
 						Spec: corev1.PodSpec{
-							ServiceAccountName: "gitstar-operator",
+							ServiceAccountName: template.ServiceAccountName,
+							ImagePullSecrets:   template.ImagePullSecrets,
+							NodeSelector:       template.NodeSelector,
+							Tolerations:        template.Tolerations,
+							SecurityContext:    template.PodSecurityContext,
 							Containers: []corev1.Container{
 								{
-									Name:  cr.Name + "-gitstarjob",
-									Image: "kurisux/gitstar-queryjob:latest",
-									Env: []corev1.EnvVar{
-										{
-											Name:  ENVGitStarName,
-											Value: cr.Name,
-										},
-										{
-											Name:  ENVGitStarNameSpace,
-											Value: cr.Namespace,
-										},
-									},
+									Name:            cr.Name + "-gitstarjob",
+									Image:           template.Image,
+									ImagePullPolicy: template.ImagePullPolicy,
+									Env:             env,
+									Resources:       resources,
+									SecurityContext: template.SecurityContext,
 								},
 							},
 							RestartPolicy: corev1.RestartPolicyNever,
@@ -67,10 +224,22 @@ func NewCronJobForCR(cr *appv1.GitStar) *batchv1.CronJob {
 				},
 			},
 			ConcurrencyPolicy:          batchv1.ReplaceConcurrent,
-			SuccessfulJobsHistoryLimit: &CronJobHistoryLimit,
-			FailedJobsHistoryLimit:     &CronJobHistoryLimit,
+			SuccessfulJobsHistoryLimit: &successfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     &failedJobsHistoryLimit,
 		},
 	}
+	cronJob.Annotations = map[string]string{SpecHashAnnotation: hashSpec(CronJobGroupVersion(), &cronJob.Spec)}
+	return cronJob
+}
+
+// hashSpec hashes the spec with the API version, so the CronJobs are rewritten in batch/v1 once it is available
+func hashSpec(gv schema.GroupVersion, spec *batchv1.CronJobSpec) string {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		log.Error(err, "marshal CronJob spec failed!")
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(append([]byte(gv.String()), data...)))[:16]
 }
 
 // GenerateCronJobName
@@ -78,5 +247,5 @@ func GenerateCronJobName(cr *appv1.GitStar) string {
 	return fmt.Sprintf("%s-gitstar", cr.Name)
 }
 func DeleteCronJob(cr *appv1.GitStar, c client.Client) error {
-	return c.Delete(context.TODO(), NewCronJobForCR(cr))
+	return writeCronJob(NewCronJobForCR(cr, nil), func(obj runtime.Object) error { return c.Delete(context.TODO(), obj) })
 }
@@ -0,0 +1,131 @@
+package resource
+
+import (
+	"context"
+	"sync"
+
+	batchv1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// BatchV1 serves CronJobs since Kubernetes 1.21, BatchV1beta1 until Kubernetes 1.24
+	BatchV1      = schema.GroupVersion{Group: "batch", Version: "v1"}
+	BatchV1beta1 = batchv1.SchemeGroupVersion
+
+	cronJobGroupVersionMu sync.RWMutex
+	cronJobGroupVersion   = BatchV1beta1
+)
+
+// DetectCronJobGroupVersion asks the apiserver for the API version of CronJobs, batch/v1 if it is served
+func DetectCronJobGroupVersion(d discovery.DiscoveryInterface) (schema.GroupVersion, error) {
+	resources, err := d.ServerResourcesForGroupVersion(BatchV1.String())
+	if err != nil {
+		return schema.GroupVersion{}, err
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == "cronjobs" {
+			return BatchV1, nil
+		}
+	}
+	return BatchV1beta1, nil
+}
+
+// SetCronJobGroupVersion sets the API version the CronJobs are read and written with, default batch/v1beta1
+func SetCronJobGroupVersion(gv schema.GroupVersion) {
+	cronJobGroupVersionMu.Lock()
+	defer cronJobGroupVersionMu.Unlock()
+	cronJobGroupVersion = gv
+}
+
+// CronJobGroupVersion returns the API version the CronJobs are read and written with
+func CronJobGroupVersion() schema.GroupVersion {
+	cronJobGroupVersionMu.RLock()
+	defer cronJobGroupVersionMu.RUnlock()
+	return cronJobGroupVersion
+}
+
+// The CronJobs are built as batch/v1beta1 objects, whose fields are a subset of the batch/v1 ones. With batch/v1
+// they are converted to unstructured objects, since the client doesn't have the batch/v1 types.
+
+// GetCronJob reads a CronJob with the API version of the apiserver
+func GetCronJob(ctx context.Context, c client.Reader, key types.NamespacedName) (*batchv1.CronJob, error) {
+	cronJob := &batchv1.CronJob{}
+	if CronJobGroupVersion() == BatchV1beta1 {
+		return cronJob, c.Get(ctx, key, cronJob)
+	}
+
+	u := newUnstructuredCronJob()
+	if err := c.Get(ctx, key, u); err != nil {
+		return nil, err
+	}
+	return cronJob, fromUnstructured(u, cronJob)
+}
+
+// ListCronJobs lists the CronJobs with the API version of the apiserver
+func ListCronJobs(ctx context.Context, c client.Reader, opts ...client.ListOption) (*batchv1.CronJobList, error) {
+	cronJobs := &batchv1.CronJobList{}
+	if CronJobGroupVersion() == BatchV1beta1 {
+		return cronJobs, c.List(ctx, cronJobs, opts...)
+	}
+
+	u := &unstructured.UnstructuredList{}
+	u.SetGroupVersionKind(CronJobGroupVersion().WithKind("CronJobList"))
+	if err := c.List(ctx, u, opts...); err != nil {
+		return nil, err
+	}
+	cronJobs.Items = make([]batchv1.CronJob, len(u.Items))
+	for i := range u.Items {
+		if err := fromUnstructured(&u.Items[i], &cronJobs.Items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return cronJobs, nil
+}
+
+// CreateCronJob creates cronJob with the API version of the apiserver, cronJob is set to the created object
+func CreateCronJob(ctx context.Context, c client.Writer, cronJob *batchv1.CronJob) error {
+	return writeCronJob(cronJob, func(obj runtime.Object) error { return c.Create(ctx, obj) })
+}
+
+// UpdateCronJob updates cronJob with the API version of the apiserver, cronJob is set to the updated object
+func UpdateCronJob(ctx context.Context, c client.Writer, cronJob *batchv1.CronJob) error {
+	return writeCronJob(cronJob, func(obj runtime.Object) error { return c.Update(ctx, obj) })
+}
+
+func writeCronJob(cronJob *batchv1.CronJob, write func(runtime.Object) error) error {
+	if CronJobGroupVersion() == BatchV1beta1 {
+		return write(cronJob)
+	}
+
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cronJob)
+	if err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{Object: data}
+	u.SetGroupVersionKind(CronJobGroupVersion().WithKind("CronJob"))
+	if err := write(u); err != nil {
+		return err
+	}
+	return fromUnstructured(u, cronJob)
+}
+
+func newUnstructuredCronJob() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(CronJobGroupVersion().WithKind("CronJob"))
+	return u
+}
+
+// fromUnstructured converts a batch/v1 CronJob, the fields batch/v1beta1 doesn't have are dropped
+func fromUnstructured(u *unstructured.Unstructured, cronJob *batchv1.CronJob) error {
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cronJob); err != nil {
+		return err
+	}
+	cronJob.SetGroupVersionKind(BatchV1beta1.WithKind("CronJob"))
+	return nil
+}
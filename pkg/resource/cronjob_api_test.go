@@ -0,0 +1,80 @@
+package resource
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+func TestDetectCronJobGroupVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []metav1.APIResource
+		want      string
+	}{
+		{name: "batch/v1", resources: []metav1.APIResource{{Name: "jobs"}, {Name: "cronjobs"}}, want: "batch/v1"},
+		{name: "batch/v1beta1", resources: []metav1.APIResource{{Name: "jobs"}}, want: "batch/v1beta1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{
+				Resources: []*metav1.APIResourceList{{GroupVersion: "batch/v1", APIResources: tt.resources}},
+			}}
+			gv, err := DetectCronJobGroupVersion(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gv.String() != tt.want {
+				t.Errorf("DetectCronJobGroupVersion() = %s, want %s", gv, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronJobBatchV1(t *testing.T) {
+	defer SetCronJobGroupVersion(BatchV1beta1)
+	gitStar := &appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kblog"},
+		Spec:       appv1.GitStarSpec{RepoName: "kuri-su/kblog"},
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme)
+	ctx := context.TODO()
+	old := NewCronJobForCR(gitStar, nil)
+
+	SetCronJobGroupVersion(BatchV1)
+	cronJob := NewCronJobForCR(gitStar, nil)
+	// the CronJobs written in batch/v1beta1 are updated, which rewrites them in batch/v1
+	if cronJob.Annotations[SpecHashAnnotation] == old.Annotations[SpecHashAnnotation] {
+		t.Error("hash didn't change with the API version")
+	}
+	if err := CreateCronJob(ctx, c, cronJob); err != nil {
+		t.Fatal(err)
+	}
+	found, err := GetCronJob(ctx, c, types.NamespacedName{Namespace: "default", Name: cronJob.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Spec.Schedule != cronJob.Spec.Schedule || found.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image != DefaultJobImage {
+		t.Errorf("spec = %+v, want the created one", found.Spec)
+	}
+
+	found.Spec.Schedule = "0 * * * *"
+	if err := UpdateCronJob(ctx, c, found); err != nil {
+		t.Fatal(err)
+	}
+	found, err = GetCronJob(ctx, c, types.NamespacedName{Namespace: "default", Name: cronJob.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Spec.Schedule != "0 * * * *" {
+		t.Errorf("schedule = %s, want the updated one", found.Spec.Schedule)
+	}
+}
@@ -0,0 +1,124 @@
+package resource
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/operatorconfig"
+)
+
+func TestNewCronJobForCR(t *testing.T) {
+	defer SetJobDefaults(appv1.GitStarJobTemplate{})
+
+	SetJobDefaults(appv1.GitStarJobTemplate{
+		Image:            "registry.example.com/gitstar-queryjob:v1",
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+		Env:              []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}},
+	})
+	runAsNonRoot := true
+	gitStar := &appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kblog"},
+		Spec: appv1.GitStarSpec{
+			RepoName: "kuri-su/kblog",
+			JobTemplate: &appv1.GitStarJobTemplate{
+				Resources: &corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+				},
+				NodeSelector:       map[string]string{"kubernetes.io/os": "linux"},
+				PodSecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: &runAsNonRoot},
+				Env: []corev1.EnvVar{
+					{Name: "HTTPS_PROXY", Value: "http://other-proxy:3128"},
+					{Name: ENVGitStarName, Value: "overridden"},
+				},
+			},
+		},
+	}
+
+	cronJob := NewCronJobForCR(gitStar, nil)
+	pod := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	container := pod.Containers[0]
+	if container.Image != "registry.example.com/gitstar-queryjob:v1" {
+		t.Errorf("image = %s, want the default of the operator", container.Image)
+	}
+	if pod.ServiceAccountName != DefaultJobServiceAccountName {
+		t.Errorf("serviceAccountName = %s, want the built-in default", pod.ServiceAccountName)
+	}
+	if len(pod.ImagePullSecrets) != 1 || pod.NodeSelector["kubernetes.io/os"] != "linux" ||
+		pod.SecurityContext == nil || container.Resources.Limits.Memory().String() != "64Mi" {
+		t.Errorf("pod = %+v, want the overlay of the GitStar", pod)
+	}
+
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	want := map[string]string{
+		ENVGitStarName:       "kblog",
+		ENVGitStarNameSpace:  "default",
+		ENVGitStarConfigName: operatorconfig.Name,
+		"HTTPS_PROXY":        "http://other-proxy:3128",
+
+		ENVGitStarCredentialsNamespace: operatorconfig.DefaultCredentialsNamespace,
+		ENVGitStarCredentialsName:      operatorconfig.DefaultCredentialsName,
+		ENVGitStarCredentialsKey:       operatorconfig.DefaultCredentialsKey,
+	}
+	if len(env) != len(want) {
+		t.Errorf("env = %v, want %v", env, want)
+	}
+	for name, value := range want {
+		if env[name] != value {
+			t.Errorf("env %s = %q, want %q", name, env[name], value)
+		}
+	}
+
+	// the hash changes with the template only
+	if hash := NewCronJobForCR(gitStar, nil).Annotations[SpecHashAnnotation]; hash != cronJob.Annotations[SpecHashAnnotation] {
+		t.Errorf("hash of the same template changed from %s to %s", cronJob.Annotations[SpecHashAnnotation], hash)
+	}
+	gitStar.Spec.JobTemplate.Image = "registry.example.com/gitstar-queryjob:v2"
+	if hash := NewCronJobForCR(gitStar, nil).Annotations[SpecHashAnnotation]; hash == cronJob.Annotations[SpecHashAnnotation] {
+		t.Error("hash didn't change with the image")
+	}
+}
+
+func TestNewCronJobForCRWithSettings(t *testing.T) {
+	settings := operatorconfig.DefaultSettings()
+	settings.Schedule = "*/30 * * * *"
+	settings.SuccessfulJobsHistoryLimit = 1
+	settings.JobTemplate = &appv1.GitStarJobTemplate{
+		Image:        "registry.example.com/gitstar-queryjob:v1",
+		NodeSelector: map[string]string{"kubernetes.io/os": "linux"},
+	}
+	gitStar := &appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kblog"},
+		Spec: appv1.GitStarSpec{
+			RepoName:    "kuri-su/kblog",
+			JobTemplate: &appv1.GitStarJobTemplate{Image: "registry.example.com/gitstar-queryjob:v2"},
+		},
+	}
+
+	cronJob := NewCronJobForCR(gitStar, &settings)
+	pod := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	if cronJob.Spec.Schedule != "*/30 * * * *" || *cronJob.Spec.SuccessfulJobsHistoryLimit != 1 ||
+		*cronJob.Spec.FailedJobsHistoryLimit != operatorconfig.DefaultJobsHistoryLimit {
+		t.Errorf("spec = %+v, want the settings", cronJob.Spec)
+	}
+	if pod.Containers[0].Image != "registry.example.com/gitstar-queryjob:v2" || pod.NodeSelector["kubernetes.io/os"] != "linux" {
+		t.Errorf("pod = %+v, want the GitStar overlaying the settings", pod)
+	}
+
+	// the hash changes with the schedule
+	if NewCronJobForCR(gitStar, nil).Annotations[SpecHashAnnotation] == cronJob.Annotations[SpecHashAnnotation] {
+		t.Error("hash didn't change with the settings")
+	}
+
+	// spec.schedule of a v2 GitStar overrides the settings
+	gitStar.Annotations = map[string]string{appv1.ConversionDataAnnotation: `{"schedule":"0 0 * * *"}`}
+	if schedule := NewCronJobForCR(gitStar, &settings).Spec.Schedule; schedule != "0 0 * * *" {
+		t.Errorf("schedule = %s, want the one of the GitStar", schedule)
+	}
+}
@@ -0,0 +1,229 @@
+package webserver
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+const (
+	badgePath = "/badge/"
+
+	badgeMetricStars  = "stars"
+	badgeMetricForks  = "forks"
+	badgeMetricGrowth = "growth"
+
+	// badges are fresh when the GitStar was fetched within badgeFreshAge, stale after badgeStaleAge
+	badgeFreshAge = 2 * time.Hour
+	badgeStaleAge = 24 * time.Hour
+
+	badgeColorFresh   = "#4c1"
+	badgeColorAging   = "#dfb317"
+	badgeColorStale   = "#e05d44"
+	badgeColorUnknown = "#9f9f9f"
+
+	badgeMaxAge = 300
+)
+
+var badgeTemplate = template.Must(template.New("badge").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Value}}">
+<title>{{.Label}}: {{.Value}}</title>
+<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="{{.LabelWidth}}" height="20" fill="#555"/><rect x="{{.LabelWidth}}" width="{{.ValueWidth}}" height="20" fill="{{.Color}}"/><rect width="{{.Width}}" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="{{.LabelX}}" y="15" fill="#010101" fill-opacity=".3">{{.Label}}</text><text x="{{.LabelX}}" y="14">{{.Label}}</text>
+<text x="{{.ValueX}}" y="15" fill="#010101" fill-opacity=".3">{{.Value}}</text><text x="{{.ValueX}}" y="14">{{.Value}}</text>
+</g>
+</svg>
+`))
+
+// badge is the data rendered by badgeTemplate, Label and Value are escaped
+type badge struct {
+	Label, Value, Color                           string
+	Width, LabelWidth, ValueWidth, LabelX, ValueX int
+}
+
+func newBadge(label, value, color string) *badge {
+	b := &badge{
+		Label:      html.EscapeString(label),
+		Value:      html.EscapeString(value),
+		Color:      color,
+		LabelWidth: textWidth(label) + 10,
+		ValueWidth: textWidth(value) + 10,
+	}
+	b.Width = b.LabelWidth + b.ValueWidth
+	b.LabelX = b.LabelWidth / 2
+	b.ValueX = b.LabelWidth + b.ValueWidth/2
+	return b
+}
+
+// textWidth estimates the width in pixels of text in 11px Verdana
+func textWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("ijlt.,:;|!' ", r):
+			width += 4
+		case r >= 'A' && r <= 'Z', strings.ContainsRune("mw", r):
+			width += 9
+		default:
+			width += 7
+		}
+	}
+	return width
+}
+
+// serveBadge serves /badge/{namespace}/{name}.svg, the metric query parameter chooses stars (default), forks or
+// growth and the label query parameter overrides the left text. Badges are meant to be embedded in READMEs,
+// they are served without authentication.
+func (s *Server) serveBadge(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, badgePath), "/")
+	if len(parts) != 2 || parts[0] == "" || !strings.HasSuffix(parts[1], ".svg") || parts[1] == ".svg" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	key := types.NamespacedName{Namespace: parts[0], Name: strings.TrimSuffix(parts[1], ".svg")}
+
+	metric := req.URL.Query().Get("metric")
+	if metric == "" {
+		metric = badgeMetricStars
+	}
+	if metric != badgeMetricStars && metric != badgeMetricForks && metric != badgeMetricGrowth {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("metric '%s' is invalid, must be one of stars, forks, growth", metric))
+		return
+	}
+	label := req.URL.Query().Get("label")
+	if label == "" {
+		label = metric
+	}
+
+	gitStar := &appv1.GitStar{}
+	err := s.reader.Get(req.Context(), key, gitStar)
+	if errors.IsNotFound(err) {
+		s.writeBadge(w, req, newBadge(label, "not found", badgeColorUnknown), time.Time{})
+		return
+	} else if err != nil {
+		log.Error(err, "get GitStar failed!")
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var value string
+	switch metric {
+	case badgeMetricStars:
+		value = formatCount(gitStar.Status.StarNumber)
+	case badgeMetricForks:
+		value = formatCount(gitStar.Status.ForkNumber)
+	case badgeMetricGrowth:
+		growth, ok, err := s.growthOf(req, gitStar, req.URL.Query().Get("leaderboard"))
+		if err != nil {
+			log.Error(err, "get growth of GitStar failed!")
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		value = "n/a"
+		if ok {
+			value = formatCount(growth)
+			if growth >= 0 {
+				value = "+" + value
+			}
+		}
+	}
+
+	updatedAt := gitStar.Status.UpdatedAt.Time
+	s.writeBadge(w, req, newBadge(label, value, freshnessColor(gitStar, time.Now())), updatedAt)
+}
+
+// growthOf looks the growth of a GitStar up in the named GitStarLeaderboard of its namespace, or in the first
+// leaderboard ranking it when name is empty
+func (s *Server) growthOf(req *http.Request, gitStar *appv1.GitStar, name string) (int64, bool, error) {
+	leaderboards := &appv1.GitStarLeaderboardList{}
+	if err := s.reader.List(req.Context(), leaderboards, client.InNamespace(gitStar.Namespace)); err != nil {
+		return 0, false, err
+	}
+	for _, leaderboard := range leaderboards.Items {
+		if name != "" && leaderboard.Name != name {
+			continue
+		}
+		for _, entry := range leaderboard.Status.Entries {
+			if entry.Name == gitStar.Name {
+				return entry.Growth, true, nil
+			}
+		}
+	}
+	return 0, false, nil
+}
+
+func (s *Server) writeBadge(w http.ResponseWriter, req *http.Request, b *badge, updatedAt time.Time) {
+	var buf bytes.Buffer
+	if err := badgeTemplate.Execute(&buf, b); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(buf.Bytes()))
+	w.Header().Set("Content-Type", "image/svg+xml;charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(badgeMaxAge))
+	w.Header().Set("ETag", etag)
+	if updatedAt.Unix() > 0 {
+		w.Header().Set("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+	}
+	if req.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+// freshnessColor colors a badge by the time since the last successful fetch of the GitStar
+func freshnessColor(gitStar *appv1.GitStar, now time.Time) string {
+	updatedAt := gitStar.Status.UpdatedAt.Time
+	switch {
+	case updatedAt.Unix() <= 0:
+		return badgeColorUnknown
+	case gitStar.Status.FailedReason != "" || now.Sub(updatedAt) >= badgeStaleAge:
+		return badgeColorStale
+	case now.Sub(updatedAt) >= badgeFreshAge:
+		return badgeColorAging
+	default:
+		return badgeColorFresh
+	}
+}
+
+// formatCount formats a count like shields.io, e.g. 999, 1.2k, 70k, 1.5M
+func formatCount(n int64) string {
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	switch {
+	case n < 1000:
+		return sign + strconv.FormatInt(n, 10)
+	case n < 10000:
+		return sign + strings.TrimSuffix(strconv.FormatFloat(float64(n)/1000, 'f', 1, 64), ".0") + "k"
+	case n < 1000000:
+		return sign + strconv.FormatInt(n/1000, 10) + "k"
+	default:
+		return sign + strings.TrimSuffix(strconv.FormatFloat(float64(n)/1000000, 'f', 1, 64), ".0") + "M"
+	}
+}
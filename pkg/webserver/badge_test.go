@@ -0,0 +1,122 @@
+package webserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+func TestServeBadge(t *testing.T) {
+	fresh := newTestGitStar("default", "kubernetes", "kubernetes/kubernetes", 70123, nil)
+	fresh.Status.ForkNumber = 1234
+	fresh.Status.UpdatedAt = metav1.NewTime(time.Now().Add(-time.Minute))
+	stale := newTestGitStar("default", "kblog", "kuri-su/kblog", 42, nil)
+	leaderboard := &appv1.GitStarLeaderboard{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "weekly"},
+		Status: appv1.GitStarLeaderboardStatus{
+			Entries: []appv1.GitStarLeaderboardEntry{{Rank: 1, Name: "kubernetes", Growth: 321}},
+		},
+	}
+	server := New(newTestReader(t, fresh, stale, leaderboard), Options{Token: "secret"})
+
+	tests := []struct {
+		name      string
+		path      string
+		wantCode  int
+		wantText  []string
+		wantColor string
+	}{
+		{
+			name:      "stars of fresh GitStar",
+			path:      "/badge/default/kubernetes.svg",
+			wantCode:  http.StatusOK,
+			wantText:  []string{"stars", "70k"},
+			wantColor: badgeColorFresh,
+		},
+		{
+			name:      "forks with label",
+			path:      "/badge/default/kubernetes.svg?metric=forks&label=k8s%20forks",
+			wantCode:  http.StatusOK,
+			wantText:  []string{"k8s forks", "1.2k"},
+			wantColor: badgeColorFresh,
+		},
+		{
+			name:      "growth from leaderboard",
+			path:      "/badge/default/kubernetes.svg?metric=growth",
+			wantCode:  http.StatusOK,
+			wantText:  []string{"growth", "+321"},
+			wantColor: badgeColorFresh,
+		},
+		{
+			name:      "growth without leaderboard",
+			path:      "/badge/default/kblog.svg?metric=growth",
+			wantCode:  http.StatusOK,
+			wantText:  []string{"n/a"},
+			wantColor: badgeColorStale,
+		},
+		{
+			name:      "missing GitStar",
+			path:      "/badge/default/missing.svg",
+			wantCode:  http.StatusOK,
+			wantText:  []string{"not found"},
+			wantColor: badgeColorUnknown,
+		},
+		{
+			name:     "invalid metric",
+			path:     "/badge/default/kubernetes.svg?metric=issues",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid path",
+			path:     "/badge/default/kubernetes",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d, body %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			body := rec.Body.String()
+			for _, text := range append(tt.wantText, tt.wantColor) {
+				if !strings.Contains(body, text) {
+					t.Errorf("badge doesn't contain %q: %s", text, body)
+				}
+			}
+			if rec.Header().Get("ETag") == "" || !strings.HasPrefix(rec.Header().Get("Cache-Control"), "public") {
+				t.Errorf("missing caching headers: %v", rec.Header())
+			}
+
+			// the same badge is not modified
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+			rec = httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			if rec.Code != http.StatusNotModified {
+				t.Errorf("code with If-None-Match = %d, want %d", rec.Code, http.StatusNotModified)
+			}
+		})
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := map[int64]string{0: "0", 999: "999", 1000: "1k", 1234: "1.2k", 70123: "70k", 1500000: "1.5M", -12: "-12"}
+	for n, want := range tests {
+		if got := formatCount(n); got != want {
+			t.Errorf("formatCount(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
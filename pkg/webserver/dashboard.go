@@ -0,0 +1,290 @@
+package webserver
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+	"gitstar-operator/pkg/resource"
+)
+
+const (
+	dashboardPath = "/"
+
+	cronJobHealthOK        = "ok"
+	cronJobHealthRunning   = "running"
+	cronJobHealthLate      = "late"
+	cronJobHealthSuspended = "suspended"
+	cronJobHealthMissing   = "missing"
+
+	// cronJobLateAge is the time without a schedule after which the hourly CronJob of a GitStar is late
+	cronJobLateAge = 2 * time.Hour
+)
+
+// dashboardSortKeys are the columns the dashboard can be sorted by
+var dashboardSortKeys = map[string]func(a, b *dashboardRow) bool{
+	"namespace": func(a, b *dashboardRow) bool { return a.Namespace < b.Namespace },
+	"name":      func(a, b *dashboardRow) bool { return a.Name < b.Name },
+	"repo":      func(a, b *dashboardRow) bool { return a.RepoName < b.RepoName },
+	"stars":     func(a, b *dashboardRow) bool { return a.StarNumber < b.StarNumber },
+	"forks":     func(a, b *dashboardRow) bool { return a.ForkNumber < b.ForkNumber },
+	"updated":   func(a, b *dashboardRow) bool { return a.UpdatedAt.Before(b.UpdatedAt) },
+	"cronjob":   func(a, b *dashboardRow) bool { return a.CronJobHealth < b.CronJobHealth },
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>GitStar Dashboard</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 6px 12px; border-bottom: 1px solid #e1e4e8; text-align: left; }
+th a { color: inherit; text-decoration: none; }
+td.number { text-align: right; font-variant-numeric: tabular-nums; }
+.failed { color: #cb2431; }
+.health-ok { color: #28a745; }
+.health-running { color: #0366d6; }
+.health-late, .health-suspended { color: #b08800; }
+.health-missing { color: #cb2431; }
+form { margin-bottom: 1em; }
+.error { color: #cb2431; }
+</style>
+</head>
+<body>
+<h1>GitStar Dashboard</h1>
+<form method="get" action="/">
+<input type="hidden" name="sort" value="{{.Sort}}">
+<input type="hidden" name="order" value="{{.Order}}">
+<label>Namespace <input type="text" name="namespace" value="{{.Namespace}}" placeholder="all namespaces"></label>
+<label>Label selector <input type="text" name="labelSelector" value="{{.LabelSelector}}" placeholder="team=k8s"></label>
+<button type="submit">Filter</button>
+</form>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<table>
+<thead>
+<tr>{{range .Columns}}<th><a href="{{.Link}}">{{.Title}}{{.Arrow}}</a></th>{{end}}<th>Failure</th></tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr>
+<td>{{.Namespace}}</td>
+<td>{{.Name}}</td>
+<td><a href="https://github.com/{{.RepoName}}">{{.RepoName}}</a></td>
+<td class="number">{{.StarNumber}}</td>
+<td class="number">{{.ForkNumber}}</td>
+<td>{{if .UpdatedAt.IsZero}}never{{else}}{{.UpdatedAt.Format "2006-01-02 15:04:05 MST"}}{{end}}</td>
+<td class="health-{{.CronJobHealth}}">{{.CronJobHealth}}</td>
+<td class="failed">{{.FailedReason}}</td>
+</tr>
+{{else}}<tr><td colspan="8">No GitStars found.</td></tr>
+{{end}}</tbody>
+</table>
+<p>{{len .Rows}} GitStars, generated at {{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+</body>
+</html>
+`))
+
+// dashboardRow is a GitStar shown on the dashboard
+type dashboardRow struct {
+	Repo
+	UpdatedAt     time.Time
+	CronJobHealth string
+}
+
+// dashboardColumn is a sortable column header of the dashboard
+type dashboardColumn struct {
+	Title, Link, Arrow string
+}
+
+type dashboardPage struct {
+	Namespace, LabelSelector, Sort, Order, Error string
+	Columns                                      []dashboardColumn
+	Rows                                         []*dashboardRow
+	GeneratedAt                                  time.Time
+}
+
+// serveDashboard serves an HTML page listing the GitStars, filtered by the namespace and labelSelector query
+// parameters and sorted by the sort and order query parameters. The page has no scripts or external resources.
+func (s *Server) serveDashboard(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != dashboardPath {
+		http.NotFound(w, req)
+		return
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// browsers can't send a bearer token, the token is accepted as the password of basic authentication
+	if !s.authorized(req) && !s.authorizedBasic(req) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="gitstar"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := req.URL.Query()
+	page := &dashboardPage{
+		Namespace:     query.Get("namespace"),
+		LabelSelector: query.Get("labelSelector"),
+		Sort:          query.Get("sort"),
+		Order:         query.Get("order"),
+		GeneratedAt:   time.Now().UTC(),
+	}
+	if _, ok := dashboardSortKeys[page.Sort]; !ok {
+		page.Sort = "stars"
+	}
+	if page.Order != "asc" {
+		page.Order = "desc"
+	}
+
+	code := http.StatusOK
+	rows, err := s.dashboardRows(req, page.Namespace, page.LabelSelector, time.Now())
+	if err != nil {
+		code = http.StatusBadRequest
+		page.Error = err.Error()
+	}
+	sortDashboardRows(rows, page.Sort, page.Order == "desc")
+	page.Rows = rows
+	page.Columns = dashboardColumns(page)
+
+	var buf bytes.Buffer
+	if err := dashboardTemplate.Execute(&buf, page); err != nil {
+		log.Error(err, "render dashboard failed!")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+func (s *Server) authorizedBasic(req *http.Request) bool {
+	_, password, ok := req.BasicAuth()
+	return ok && subtle.ConstantTimeCompare([]byte(password), []byte(s.options.Token)) == 1
+}
+
+// dashboardRows lists the GitStars with the health of their CronJobs
+func (s *Server) dashboardRows(req *http.Request, namespace, labelSelector string, now time.Time) ([]*dashboardRow, error) {
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	gitStars := &appv1.GitStarList{}
+	err = s.reader.List(req.Context(), gitStars, client.InNamespace(namespace),
+		client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		log.Error(err, "list GitStars failed!")
+		return nil, err
+	}
+	cronJobs, err := resource.ListCronJobs(req.Context(), s.reader, client.InNamespace(namespace))
+	if err != nil {
+		log.Error(err, "list CronJobs failed!")
+		return nil, err
+	}
+	cronJobByName := make(map[types.NamespacedName]*batchv1.CronJob, len(cronJobs.Items))
+	for i := range cronJobs.Items {
+		cronJob := &cronJobs.Items[i]
+		cronJobByName[types.NamespacedName{Namespace: cronJob.Namespace, Name: cronJob.Name}] = cronJob
+	}
+
+	rows := make([]*dashboardRow, 0, len(gitStars.Items))
+	for i := range gitStars.Items {
+		gitStar := &gitStars.Items[i]
+		row := &dashboardRow{Repo: NewRepo(gitStar)}
+		if row.Repo.UpdatedAt != nil {
+			row.UpdatedAt = *row.Repo.UpdatedAt
+		}
+		cronJob := cronJobByName[types.NamespacedName{
+			Namespace: gitStar.Namespace,
+			Name:      resource.GenerateCronJobName(gitStar),
+		}]
+		row.CronJobHealth = cronJobHealth(cronJob, now)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// cronJobHealth summarizes the state of the CronJob of a GitStar, which is nil when it doesn't exist
+func cronJobHealth(cronJob *batchv1.CronJob, now time.Time) string {
+	switch {
+	case cronJob == nil:
+		return cronJobHealthMissing
+	case cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend:
+		return cronJobHealthSuspended
+	case len(cronJob.Status.Active) > 0:
+		return cronJobHealthRunning
+	}
+
+	lastSchedule := cronJob.CreationTimestamp.Time
+	if cronJob.Status.LastScheduleTime != nil {
+		lastSchedule = cronJob.Status.LastScheduleTime.Time
+	}
+	if now.Sub(lastSchedule) > cronJobLateAge {
+		return cronJobHealthLate
+	}
+	return cronJobHealthOK
+}
+
+// sortDashboardRows sorts rows by key, ties are sorted by namespace and name
+func sortDashboardRows(rows []*dashboardRow, key string, desc bool) {
+	less := dashboardSortKeys[key]
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if less(a, b) || less(b, a) {
+			return less(a, b) != desc
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
+
+// dashboardColumns builds the column headers, clicking one sorts by it and toggles the order when already sorted
+func dashboardColumns(page *dashboardPage) []dashboardColumn {
+	columns := []struct{ key, title string }{
+		{"namespace", "Namespace"},
+		{"name", "Name"},
+		{"repo", "Repository"},
+		{"stars", "Stars"},
+		{"forks", "Forks"},
+		{"updated", "Last Update"},
+		{"cronjob", "CronJob"},
+	}
+
+	result := make([]dashboardColumn, 0, len(columns))
+	for _, c := range columns {
+		order, arrow := "desc", ""
+		if c.key == page.Sort {
+			arrow = " ▼"
+			if page.Order == "desc" {
+				order = "asc"
+			} else {
+				arrow = " ▲"
+			}
+		}
+		query := url.Values{"sort": {c.key}, "order": {order}}
+		if page.Namespace != "" {
+			query.Set("namespace", page.Namespace)
+		}
+		if page.LabelSelector != "" {
+			query.Set("labelSelector", page.LabelSelector)
+		}
+		result = append(result, dashboardColumn{Title: c.title, Link: dashboardPath + "?" + query.Encode(), Arrow: arrow})
+	}
+	return result
+}
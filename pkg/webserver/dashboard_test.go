@@ -0,0 +1,150 @@
+package webserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"gitstar-operator/pkg/resource"
+)
+
+func TestServeDashboard(t *testing.T) {
+	kubernetes := newTestGitStar("default", "kubernetes", "kubernetes/kubernetes", 70000, map[string]string{"team": "k8s"})
+	kblog := newTestGitStar("blog", "kblog", "kuri-su/kblog", 42, nil)
+	kblog.Status.FailedReason = "GET https://api.github.com/repos/kuri-su/kblog: 404 Not Found"
+	cronJob := resource.NewCronJobForCR(kubernetes, nil)
+	cronJob.CreationTimestamp = metav1.NewTime(time.Now())
+	server := New(newTestReader(t, kubernetes, kblog, cronJob), Options{Token: "secret"})
+
+	tests := []struct {
+		name      string
+		path      string
+		basicAuth bool
+		wantCode  int
+		wantOrder []string
+		wantText  []string
+	}{
+		{
+			name:     "unauthorized",
+			path:     "/",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:      "sorted by stars",
+			path:      "/",
+			basicAuth: true,
+			wantCode:  http.StatusOK,
+			wantOrder: []string{"kubernetes/kubernetes", "kuri-su/kblog"},
+			wantText:  []string{"health-ok", "health-missing", "404 Not Found"},
+		},
+		{
+			name:      "sorted by name ascending",
+			path:      "/?sort=name&order=asc",
+			basicAuth: true,
+			wantCode:  http.StatusOK,
+			wantOrder: []string{"kuri-su/kblog", "kubernetes/kubernetes"},
+		},
+		{
+			name:      "filtered by namespace",
+			path:      "/?namespace=blog",
+			basicAuth: true,
+			wantCode:  http.StatusOK,
+			wantOrder: []string{"kuri-su/kblog"},
+		},
+		{
+			name:      "filtered by label",
+			path:      "/?labelSelector=team%3Dk8s",
+			basicAuth: true,
+			wantCode:  http.StatusOK,
+			wantOrder: []string{"kubernetes/kubernetes"},
+		},
+		{
+			name:      "invalid label selector",
+			path:      "/?labelSelector=team%3D%3D%3D",
+			basicAuth: true,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "unknown path",
+			path:      "/unknown",
+			basicAuth: true,
+			wantCode:  http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.basicAuth {
+				req.SetBasicAuth("admin", "secret")
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d, body %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			body := rec.Body.String()
+			for _, text := range tt.wantText {
+				if !strings.Contains(body, text) {
+					t.Errorf("dashboard doesn't contain %q", text)
+				}
+			}
+			last := -1
+			for _, repo := range tt.wantOrder {
+				i := strings.Index(body, ">"+repo+"<")
+				if i <= last {
+					t.Errorf("%s is missing or not in order in %s", repo, body)
+				}
+				last = i
+			}
+			if n := strings.Count(body, "<tr>\n<td>"); tt.wantOrder != nil && n != len(tt.wantOrder) {
+				t.Errorf("got %d rows, want %d", n, len(tt.wantOrder))
+			}
+		})
+	}
+}
+
+func TestCronJobHealth(t *testing.T) {
+	now := time.Date(2020, 4, 20, 12, 0, 0, 0, time.UTC)
+	suspend := true
+	tests := []struct {
+		name    string
+		cronJob *batchv1.CronJob
+		want    string
+	}{
+		{name: "missing", want: cronJobHealthMissing},
+		{
+			name:    "suspended",
+			cronJob: &batchv1.CronJob{Spec: batchv1.CronJobSpec{Suspend: &suspend}},
+			want:    cronJobHealthSuspended,
+		},
+		{
+			name: "late",
+			cronJob: &batchv1.CronJob{Status: batchv1.CronJobStatus{
+				LastScheduleTime: &metav1.Time{Time: now.Add(-3 * time.Hour)},
+			}},
+			want: cronJobHealthLate,
+		},
+		{
+			name: "scheduled recently",
+			cronJob: &batchv1.CronJob{Status: batchv1.CronJobStatus{
+				LastScheduleTime: &metav1.Time{Time: now.Add(-50 * time.Minute)},
+			}},
+			want: cronJobHealthOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cronJobHealth(tt.cronJob, now); got != tt.want {
+				t.Errorf("cronJobHealth() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
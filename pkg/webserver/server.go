@@ -0,0 +1,244 @@
+// Package webserver serves the GitStar data over HTTP from the informer cache of the manager, for readers
+// without Kubernetes RBAC: a JSON API, SVG badges and an HTML dashboard.
+package webserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+const (
+	reposPath = "/api/v1/repos"
+
+	shutdownTimeout = 5 * time.Second
+)
+
+var log = logf.Log.WithName("webserver")
+
+// Options configures the Server
+type Options struct {
+	// BindAddress is the address the server listens on, like ":8080"
+	BindAddress string
+	// Token is the bearer token required by the API, no authentication when empty
+	Token string
+	// CORSAllowedOrigins are the origins allowed to call the API from a browser, "*" allows all of them
+	CORSAllowedOrigins []string
+}
+
+// blank assignment to verify that Server implements manager.Runnable
+var _ manager.Runnable = &Server{}
+
+// Server serves the GitStar data read from reader
+type Server struct {
+	reader  client.Reader
+	options Options
+	mux     *http.ServeMux
+}
+
+// Repo is the JSON representation of a GitStar
+type Repo struct {
+	Namespace    string            `json:"namespace"`
+	Name         string            `json:"name"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	RepoName     string            `json:"repoName"`
+	StarNumber   int64             `json:"starNumber"`
+	ForkNumber   int64             `json:"forkNumber"`
+	UpdatedAt    *time.Time        `json:"updatedAt,omitempty"`
+	FailedReason string            `json:"failedReason,omitempty"`
+}
+
+// RepoList is the JSON representation of a list of GitStars
+type RepoList struct {
+	Items []Repo `json:"items"`
+}
+
+// New creates a Server reading the GitStars from reader, usually the cache of the manager
+func New(reader client.Reader, options Options) *Server {
+	s := &Server{
+		reader:  reader,
+		options: options,
+		mux:     http.NewServeMux(),
+	}
+	s.mux.Handle(reposPath, s.api(s.listRepos))
+	s.mux.Handle(reposPath+"/", s.api(s.getRepo))
+	s.mux.HandleFunc(badgePath, s.serveBadge)
+	s.mux.HandleFunc(dashboardPath, s.serveDashboard)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mux.ServeHTTP(w, req)
+}
+
+// Start serves HTTP on the bind address until stop is closed, it implements manager.Runnable
+func (s *Server) Start(stop <-chan struct{}) error {
+	listener, err := net.Listen("tcp", s.options.BindAddress)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: s}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info("Starting the web server", "address", listener.Addr().String())
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-stop:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return server.Shutdown(ctx)
+	}
+}
+
+// api wraps an API handler with CORS, authentication and the method check
+func (s *Server) api(handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.setCORSHeaders(w, req)
+		if req.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !s.authorized(req) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gitstar"`)
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		handler(w, req)
+	})
+}
+
+func (s *Server) authorized(req *http.Request) bool {
+	if s.options.Token == "" {
+		return true
+	}
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(s.options.Token)) == 1
+}
+
+func (s *Server) setCORSHeaders(w http.ResponseWriter, req *http.Request) {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	for _, allowed := range s.options.CORSAllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+			w.Header().Add("Vary", "Origin")
+			return
+		}
+	}
+}
+
+// listRepos serves the GitStars of all namespaces, the namespace and labelSelector query parameters filter them
+func (s *Server) listRepos(w http.ResponseWriter, req *http.Request) {
+	selector, err := labels.Parse(req.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	gitStars := &appv1.GitStarList{}
+	err = s.reader.List(req.Context(), gitStars,
+		client.InNamespace(req.URL.Query().Get("namespace")),
+		client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		log.Error(err, "list GitStars failed!")
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	list := RepoList{Items: make([]Repo, 0, len(gitStars.Items))}
+	for i := range gitStars.Items {
+		list.Items = append(list.Items, NewRepo(&gitStars.Items[i]))
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		if list.Items[i].Namespace != list.Items[j].Namespace {
+			return list.Items[i].Namespace < list.Items[j].Namespace
+		}
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	writeJSON(w, http.StatusOK, list)
+}
+
+// getRepo serves the GitStar at /api/v1/repos/{namespace}/{name}
+func (s *Server) getRepo(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, reposPath+"/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	gitStar := &appv1.GitStar{}
+	err := s.reader.Get(req.Context(), types.NamespacedName{Namespace: parts[0], Name: parts[1]}, gitStar)
+	if errors.IsNotFound(err) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	} else if err != nil {
+		log.Error(err, "get GitStar failed!")
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, NewRepo(gitStar))
+}
+
+// NewRepo converts a GitStar to its JSON representation
+func NewRepo(gitStar *appv1.GitStar) Repo {
+	repo := Repo{
+		Namespace:    gitStar.Namespace,
+		Name:         gitStar.Name,
+		Labels:       gitStar.Labels,
+		RepoName:     gitStar.Spec.RepoName,
+		StarNumber:   gitStar.Status.StarNumber,
+		ForkNumber:   gitStar.Status.ForkNumber,
+		FailedReason: gitStar.Status.FailedReason,
+	}
+	if gitStar.Status.UpdatedAt.Unix() > 0 {
+		updatedAt := gitStar.Status.UpdatedAt.UTC()
+		repo.UpdatedAt = &updatedAt
+	}
+	return repo
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(err, "write response failed!")
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
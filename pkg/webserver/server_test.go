@@ -0,0 +1,175 @@
+package webserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"gitstar-operator/pkg/apis"
+	appv1 "gitstar-operator/pkg/apis/app/v1"
+)
+
+func newTestReader(t *testing.T, objs ...runtime.Object) client.Reader {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewFakeClientWithScheme(s, objs...)
+}
+
+func newTestGitStar(namespace, name, repoName string, stars int64, labels map[string]string) *appv1.GitStar {
+	return &appv1.GitStar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec:       appv1.GitStarSpec{RepoName: repoName},
+		Status: appv1.GitStarStatus{
+			StarNumber: stars,
+			UpdatedAt:  metav1.NewTime(time.Date(2020, 4, 20, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+}
+
+func TestServer(t *testing.T) {
+	reader := newTestReader(t,
+		newTestGitStar("default", "kubernetes", "kubernetes/kubernetes", 70000, map[string]string{"team": "k8s"}),
+		newTestGitStar("blog", "kblog", "kuri-su/kblog", 42, nil),
+	)
+	server := New(reader, Options{Token: "secret", CORSAllowedOrigins: []string{"https://portal.example.com"}})
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		header    map[string]string
+		wantCode  int
+		wantRepo  string
+		wantRepos []string
+		wantCORS  string
+	}{
+		{
+			name:     "unauthorized",
+			path:     "/api/v1/repos",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "wrong token",
+			path:     "/api/v1/repos",
+			header:   map[string]string{"Authorization": "Bearer wrong"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:      "list all namespaces",
+			path:      "/api/v1/repos",
+			header:    map[string]string{"Authorization": "Bearer secret"},
+			wantCode:  http.StatusOK,
+			wantRepos: []string{"kuri-su/kblog", "kubernetes/kubernetes"},
+		},
+		{
+			name:      "list one namespace",
+			path:      "/api/v1/repos?namespace=blog",
+			header:    map[string]string{"Authorization": "Bearer secret"},
+			wantCode:  http.StatusOK,
+			wantRepos: []string{"kuri-su/kblog"},
+		},
+		{
+			name:      "list by label",
+			path:      "/api/v1/repos?labelSelector=team%3Dk8s",
+			header:    map[string]string{"Authorization": "Bearer secret"},
+			wantCode:  http.StatusOK,
+			wantRepos: []string{"kubernetes/kubernetes"},
+		},
+		{
+			name:     "get",
+			path:     "/api/v1/repos/blog/kblog",
+			header:   map[string]string{"Authorization": "Bearer secret"},
+			wantCode: http.StatusOK,
+			wantRepo: "kuri-su/kblog",
+		},
+		{
+			name:     "get missing",
+			path:     "/api/v1/repos/blog/missing",
+			header:   map[string]string{"Authorization": "Bearer secret"},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "method not allowed",
+			method:   http.MethodPost,
+			path:     "/api/v1/repos",
+			header:   map[string]string{"Authorization": "Bearer secret"},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "preflight of allowed origin",
+			method:   http.MethodOptions,
+			path:     "/api/v1/repos",
+			header:   map[string]string{"Origin": "https://portal.example.com"},
+			wantCode: http.StatusNoContent,
+			wantCORS: "https://portal.example.com",
+		},
+		{
+			name:     "other origin",
+			method:   http.MethodOptions,
+			path:     "/api/v1/repos",
+			header:   map[string]string{"Origin": "https://evil.example.com"},
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d, body %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantCORS {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantCORS)
+			}
+			if tt.wantRepo != "" {
+				var repo Repo
+				if err := json.Unmarshal(rec.Body.Bytes(), &repo); err != nil {
+					t.Fatal(err)
+				}
+				if repo.RepoName != tt.wantRepo || repo.UpdatedAt == nil {
+					t.Errorf("repo = %+v, want %s", repo, tt.wantRepo)
+				}
+			}
+			if tt.wantRepos == nil {
+				return
+			}
+
+			var list RepoList
+			if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+				t.Fatal(err)
+			}
+			repos := list.Items
+			if len(repos) != len(tt.wantRepos) {
+				t.Fatalf("repos = %+v, want %v", repos, tt.wantRepos)
+			}
+			for i, repo := range repos {
+				if repo.RepoName != tt.wantRepos[i] {
+					t.Errorf("repos[%d] = %s, want %s", i, repo.RepoName, tt.wantRepos[i])
+				}
+			}
+		})
+	}
+}